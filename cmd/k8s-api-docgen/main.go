@@ -17,18 +17,52 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/EnterpriseDB/k8s-api-docgen/internal/docgen"
 	"github.com/EnterpriseDB/k8s-api-docgen/internal/log"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/conventions"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/drift"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/filter"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/lint"
 	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
 )
 
+// ruleFlag is a repeatable command line flag adding a rule to a filter
+type ruleFlag struct {
+	rules *[]filter.Rule
+}
+
+func (f ruleFlag) String() string {
+	if f.rules == nil {
+		return ""
+	}
+	var values []string
+	for _, rule := range *f.rules {
+		values = append(values, fmt.Sprintf("%+v", rule))
+	}
+	return strings.Join(values, " ")
+}
+
+func (f ruleFlag) Set(value string) error {
+	*f.rules = append(*f.rules, filter.ParseRule(value))
+	return nil
+}
+
 func main() {
+	input := flag.String("input", string(docgen.InputTypeGo),
+		`Input format. The supported ones are "go" (Go packages or files), "crd" `+
+			`(CustomResourceDefinition manifests, or directories containing them) and "model" `+
+			`(models previously generated with "-t model")`)
 	format := flag.String("t", string(docgen.OutputTypeJSON),
-		`Output format. The only supported ones are "json" (JSON) and "md" (Markdown)`)
+		`Output format. The only supported ones are "json" (JSON), "json-legacy" (the JSON format `+
+			`of the previous versions), "md" (Markdown) and "model" (the model of the types, which `+
+			`can be rendered later with "-input model")`)
 	out := flag.String("o", "", "Write output to the given named file. By default "+
 		"the output will be written to stdout")
 	mdConfiguration := flag.String("c", "md-configuration.yaml",
@@ -37,11 +71,31 @@ func main() {
 	mdTemplate := flag.String("m", "md-template.md",
 		"Path of the Markdown template file for generating Markdown documentation. By default the "+
 			"Markdown template will be read from 'md-template.md'")
+	buildTags := flag.String("tags", "",
+		"Comma-separated list of build tags to be considered while loading packages")
+	var commandLineFilter filter.Filter
+	flag.Var(ruleFlag{rules: &commandLineFilter.Include}, "include",
+		"Document only the types matching the given rule, i.e. 'name=Cluster*,groupVersion=*/v1,category=core'. "+
+			"Patterns are globs or, with the 're:' prefix, regular expressions. Can be repeated")
+	flag.Var(ruleFlag{rules: &commandLineFilter.Exclude}, "exclude",
+		"Don't document the types matching the given rule, with the same syntax of -include. Can be repeated")
+	lintMode := flag.Bool("lint", false, "Check the documentation coverage instead of generating "+
+		"the documentation, reporting the undocumented types, fields and enum values")
+	minCoverage := flag.Float64("min-coverage", 0, "Minimum documentation coverage percentage "+
+		"required by -lint, which otherwise fails")
+	conventionsMode := flag.Bool("conventions", false, "Check that the types follow the Kubernetes "+
+		"API conventions, reporting the violations together with the other problems")
+	checkCRD := flag.String("check-crd", "", "Comma-separated list of CRD manifests, or directories "+
+		"containing them, to be compared with the Go types, reporting the differences together with the other problems")
+	diagnosticsFormat := flag.String("diagnostics-format", string(diagnostics.FormatText),
+		`Format of the problems found in the source code. The supported ones are "text", "json" and "sarif"`)
+	diagnosticsOut := flag.String("diagnostics-output", "", "Write the problems found in the source code "+
+		"to the given named file. By default they will be written to stderr")
 
 	CommandLine := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 
 	flag.Usage = func() {
-		_, _ = fmt.Fprintf(CommandLine.Output(), "Usage:\n  k8s-api-docgen [flags] path\n\n")
+		_, _ = fmt.Fprintf(CommandLine.Output(), "Usage:\n  k8s-api-docgen [flags] packages|files|directories\n\n")
 		flag.PrintDefaults()
 	}
 
@@ -52,28 +106,132 @@ func main() {
 		return
 	}
 
-	if *format != string(docgen.OutputTypeJSON) && *format != string(docgen.OutputTypeMD) {
+	switch docgen.OutputType(*format) {
+	case docgen.OutputTypeJSON, docgen.OutputTypeJSONLegacy, docgen.OutputTypeMD, docgen.OutputTypeModel:
+	default:
 		fmt.Printf("Error: %v\n", docgen.ErrorWrongOutputFormat)
 		flag.Usage()
-		return
+		os.Exit(1)
+	}
+
+	switch docgen.InputType(*input) {
+	case docgen.InputTypeGo, docgen.InputTypeCRD, docgen.InputTypeModel:
+	default:
+		fmt.Printf("Error: %v\n", docgen.ErrorWrongInputFormat)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	switch diagnostics.Format(*diagnosticsFormat) {
+	case diagnostics.FormatText, diagnostics.FormatJSON, diagnostics.FormatSARIF:
+	default:
+		fmt.Printf("Error: %v\n", diagnostics.ErrorWrongFormat)
+		flag.Usage()
+		os.Exit(1)
 	}
 
 	var kubeTypes parser.KubeTypes
-	kubeTypes, err := parser.GetKubeTypes(flag.Args())
+	var tags []string
+	if *buildTags != "" {
+		tags = strings.Split(*buildTags, ",")
+	}
+
+	kubeTypes, report, err := docgen.Load(flag.Args(), docgen.InputType(*input), tags)
 	if err != nil {
 		log.Log.Error(
-			err, "Error while parsing source files",
+			err, "Error while reading the types",
 			"args", flag.Args())
-		return
+		os.Exit(1)
 	}
 
-	output, err := docgen.Extract(kubeTypes, docgen.OutputType(*format), *mdConfiguration, *mdTemplate)
+	if *checkCRD != "" {
+		// The CRDs describe all the types, so they are compared before
+		// the hidden types and fields are removed and the types are filtered
+		crdTypes, crdReport, err := parser.GetKubeTypesFromCRDs(strings.Split(*checkCRD, ","))
+		if err != nil {
+			log.Log.Error(err, "Error while reading the CRDs", "crds", *checkCRD)
+			os.Exit(1)
+		}
+		report = append(report, crdReport...)
+		drift.Check(kubeTypes, crdTypes, &report)
+	}
+
+	// The model is written with all the types, including the hidden ones,
+	// so that it can be read back and compared with the CRDs
+	allTypes := kubeTypes
+	kubeTypes = kubeTypes.WithoutHidden()
+
+	typesFilter := commandLineFilter
+	var conventionsConfiguration conventions.Configuration
+	if _, err := os.Stat(*mdConfiguration); err == nil {
+		configurationFilter, err := filter.LoadConfiguration(*mdConfiguration)
+		if err != nil {
+			log.Log.Error(err, "Error while reading the filters", "configuration", *mdConfiguration)
+			os.Exit(1)
+		}
+		typesFilter = configurationFilter.Merge(commandLineFilter)
+
+		conventionsConfiguration, err = conventions.LoadConfiguration(*mdConfiguration)
+		if err != nil {
+			log.Log.Error(err, "Error while reading the conventions rules", "configuration", *mdConfiguration)
+			os.Exit(1)
+		}
+	}
+
+	kubeTypes, err = typesFilter.Apply(kubeTypes)
 	if err != nil {
-		log.Log.Error(err, "Error while exporting data")
+		log.Log.Error(err, "Error while filtering types")
+		os.Exit(1)
+	}
+
+	if *conventionsMode {
+		conventions.Check(kubeTypes, conventionsConfiguration, &report)
+	}
+
+	if *lintMode {
+		var coverage bytes.Buffer
+		if err = lint.Check(kubeTypes, *minCoverage, &report).Write(&coverage); err != nil {
+			log.Log.Error(err, "Error while writing the documentation coverage")
+			os.Exit(1)
+		}
+		if err = docgen.Output(*out, coverage.String()); err != nil {
+			log.Log.Error(err, "Cannot write output file")
+			os.Exit(1)
+		}
+	}
+
+	// The documentation is rendered before writing the diagnostics, as
+	// the references which cannot be resolved are reported while rendering
+	var output string
+	if !*lintMode {
+		outputTypes := kubeTypes
+		if docgen.OutputType(*format) == docgen.OutputTypeModel {
+			outputTypes = allTypes
+		}
+		output, err = docgen.Extract(outputTypes, docgen.OutputType(*format), *mdConfiguration, *mdTemplate, &report)
+		if err != nil {
+			log.Log.Error(err, "Error while exporting data")
+			os.Exit(1)
+		}
+	}
+
+	report.Sort()
+	err = docgen.OutputDiagnostics(*diagnosticsOut, report, diagnostics.Format(*diagnosticsFormat))
+	if err != nil {
+		log.Log.Error(err, "Cannot write diagnostics")
+		os.Exit(1)
+	}
+	if report.HasErrors() {
+		// The documentation would be incomplete, and we don't want
+		// to overwrite a good one
+		os.Exit(1)
+	}
+	if *lintMode {
 		return
 	}
 
 	if err = docgen.Output(*out, output); err != nil {
 		log.Log.Error(err, "Cannot write output file")
+		os.Exit(1)
 	}
 }
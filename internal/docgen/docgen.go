@@ -19,10 +19,13 @@ limitations under the License.
 package docgen
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
 	"github.com/EnterpriseDB/k8s-api-docgen/internal/log"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/model"
 	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
 	"github.com/EnterpriseDB/k8s-api-docgen/pkg/renderer/json"
 	"github.com/EnterpriseDB/k8s-api-docgen/pkg/renderer/md"
@@ -31,26 +34,81 @@ import (
 // ErrorWrongOutputFormat means that the used specified an output format which we don't support
 var ErrorWrongOutputFormat = fmt.Errorf("wrong output format")
 
+// ErrorWrongInputFormat means that the used specified an input format which we don't support
+var ErrorWrongInputFormat = fmt.Errorf("wrong input format")
+
+// InputType is an input type
+type InputType string
+
+const (
+	// InputTypeGo represent the Go packages or files declaring the types
+	InputTypeGo = InputType("go")
+
+	// InputTypeCRD represent the YAML manifests of the CustomResourceDefinitions
+	InputTypeCRD = InputType("crd")
+
+	// InputTypeModel represent the models written with the OutputTypeModel format
+	InputTypeModel = InputType("model")
+)
+
+// Load reads the types from the Go packages, from the CRD manifests or
+// from the models given as arguments, depending on the input format. The
+// build tags are only used for Go packages
+func Load(args []string, format InputType, buildTags []string) (parser.KubeTypes, diagnostics.Diagnostics, error) {
+	switch format {
+	case InputTypeGo:
+		return parser.GetKubeTypes(args, buildTags)
+
+	case InputTypeCRD:
+		return parser.GetKubeTypesFromCRDs(args)
+
+	case InputTypeModel:
+		return model.GetKubeTypesFromModels(args)
+
+	default:
+		return nil, nil, ErrorWrongInputFormat
+	}
+}
+
 // OutputType is an output type
 type OutputType string
 
 const (
-	// OutputTypeJSON represent the JSON output type
+	// OutputTypeJSON represent the JSON output type, which is the versioned
+	// JSON document
 	OutputTypeJSON = OutputType("json")
 
+	// OutputTypeJSONLegacy represent the JSON output type written by the
+	// previous versions, which is kept for compatibility
+	OutputTypeJSONLegacy = OutputType("json-legacy")
+
 	// OutputTypeMD represent the MarkDown output type
 	OutputTypeMD = OutputType("md")
+
+	// OutputTypeModel represent the complete model of the types, which
+	// can be read back with the InputTypeModel format
+	OutputTypeModel = OutputType("model")
 )
 
 // Extract extracts the documentation output from the list of types given the
-// output format and the markdown configuration file path
-func Extract(kubeTypes parser.KubeTypes, format OutputType, mdConfiguration string, mdTemplate string) (string, error) {
+// output format and the markdown configuration file path. The problems found
+// while rendering the documentation are added to the report
+func Extract(
+	kubeTypes parser.KubeTypes, format OutputType, mdConfiguration string, mdTemplate string,
+	report *diagnostics.Diagnostics,
+) (string, error) {
 	switch format {
 	case OutputTypeJSON:
+		return json.ToDocument(kubeTypes)
+
+	case OutputTypeJSONLegacy:
 		return json.ToJSON(kubeTypes)
 
 	case OutputTypeMD:
-		return md.ToMd(kubeTypes, mdConfiguration, mdTemplate)
+		return md.ToMd(kubeTypes, mdConfiguration, mdTemplate, report)
+
+	case OutputTypeModel:
+		return json.ToModel(kubeTypes)
 
 	default:
 		return "", ErrorWrongOutputFormat
@@ -80,3 +138,22 @@ func Output(fileName string, content string) error {
 
 	return err
 }
+
+// OutputDiagnostics writes the diagnostics in the given format to a certain
+// file. If the filename is empty the diagnostics are written to stderr,
+// where nothing is written if there are no diagnostics
+func OutputDiagnostics(fileName string, report diagnostics.Diagnostics, format diagnostics.Format) error {
+	var content bytes.Buffer
+	if err := diagnostics.Write(&content, report, format); err != nil {
+		return err
+	}
+
+	if fileName == "" {
+		if len(report) == 0 {
+			return nil
+		}
+		_, err := os.Stderr.Write(content.Bytes())
+		return err
+	}
+	return Output(fileName, content.String())
+}
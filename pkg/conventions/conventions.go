@@ -0,0 +1,246 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conventions contain the code checking that the API types follow
+// the Kubernetes API conventions, as described in
+// https://github.com/kubernetes/community/blob/master/contributors/devel/sig-architecture/api-conventions.md
+package conventions
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// conditionType is the qualified name of the type to be used for conditions
+const conditionType = "k8s.io/apimachinery/pkg/apis/meta/v1.Condition"
+
+// camelCaseRegexp matches the JSON names following the conventions
+var camelCaseRegexp = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+// Rule is a check on the API types
+type Rule struct {
+	// The rule identifier, used in the configuration and in the diagnostics
+	Name string
+
+	// What the rule checks
+	Description string
+
+	// The checks on each type and on each field, nil if the rule
+	// doesn't check them
+	checkType  func(kubeStructure parser.KubeStructure) string
+	checkField func(kubeStructure parser.KubeStructure, field parser.KubeField) string
+}
+
+// Rules is the list of the known rules, all of them enabled by default
+var Rules = []Rule{
+	{
+		Name:        "json-camel-case",
+		Description: "JSON field names should be camelCase",
+		checkField: func(kubeStructure parser.KubeStructure, field parser.KubeField) string {
+			if camelCaseRegexp.MatchString(field.Name) {
+				return ""
+			}
+			return fmt.Sprintf("the JSON name of field %v.%v should be camelCase", kubeStructure.Name, field.Name)
+		},
+	},
+	{
+		Name:        "optional-pointer",
+		Description: "optional fields should be pointers, unless they are slices or maps",
+		checkField: func(kubeStructure parser.KubeStructure, field parser.KubeField) string {
+			switch {
+			case field.Mandatory:
+				return ""
+			case kubeStructure.Resource != nil && isTopLevelField(field.Name):
+				// By convention these are values, even if optional
+				return ""
+			case field.Type.Kind == parser.TypeKindPointer,
+				field.Type.Kind == parser.TypeKindSlice,
+				field.Type.Kind == parser.TypeKindMap:
+				return ""
+			}
+			return fmt.Sprintf("optional field %v.%v should be a pointer", kubeStructure.Name, field.Name)
+		},
+	},
+	{
+		Name:        "optional-omitempty",
+		Description: "optional fields should have the omitempty JSON option",
+		checkField: func(kubeStructure parser.KubeStructure, field parser.KubeField) string {
+			if field.Mandatory || field.OmitEmpty {
+				return ""
+			}
+			return fmt.Sprintf("optional field %v.%v should have the omitempty JSON option",
+				kubeStructure.Name, field.Name)
+		},
+	},
+	{
+		Name:        "root-spec-status",
+		Description: "root Kinds should have the spec and status fields",
+		checkType: func(kubeStructure parser.KubeStructure) string {
+			if kubeStructure.Resource == nil || kubeStructure.Resource.List {
+				return ""
+			}
+
+			var missing []string
+			for _, name := range []string{"spec", "status"} {
+				if !hasField(kubeStructure, name) {
+					missing = append(missing, name)
+				}
+			}
+			if len(missing) == 0 {
+				return ""
+			}
+			return fmt.Sprintf("root Kind %v has no %v field", kubeStructure.Name, strings.Join(missing, " and "))
+		},
+	},
+	{
+		Name:        "conditions-type",
+		Description: "conditions should be a list of metav1.Condition",
+		checkField: func(kubeStructure parser.KubeStructure, field parser.KubeField) string {
+			if field.Name != "conditions" {
+				return ""
+			}
+			if field.Type.Kind == parser.TypeKindSlice && field.Type.Elem.Kind == parser.TypeKindNamed &&
+				field.Type.Elem.QualifiedName() == conditionType {
+				return ""
+			}
+			return fmt.Sprintf("field %v.%v should be a []metav1.Condition, not a %v",
+				kubeStructure.Name, field.Name, field.Type.Name)
+		},
+	},
+	{
+		Name:        "bool-field",
+		Description: "enums should be preferred to booleans, as they can be extended",
+		checkField: func(kubeStructure parser.KubeStructure, field parser.KubeField) string {
+			typeInfo := field.Type
+			if typeInfo.Kind == parser.TypeKindPointer {
+				typeInfo = *typeInfo.Elem
+			}
+			if typeInfo.Kind != parser.TypeKindNamed || typeInfo.QualifiedName() != "bool" {
+				return ""
+			}
+			return fmt.Sprintf("field %v.%v is a boolean, consider using an enum instead",
+				kubeStructure.Name, field.Name)
+		},
+	},
+}
+
+// Configuration tells which rules are enabled, indexed by rule name.
+// The rules which are not present are enabled.
+type Configuration map[string]bool
+
+// configuration is the part of the YAML configuration file we use
+type configuration struct {
+	Conventions Configuration `yaml:"conventions,omitempty"`
+}
+
+// LoadConfiguration reads the rules declared in the `conventions`
+// section of a YAML configuration file
+func LoadConfiguration(fileName string) (Configuration, error) {
+	content, err := os.ReadFile(fileName) // #nosec
+	if err != nil {
+		return nil, err
+	}
+
+	var conf configuration
+	if err = yaml.Unmarshal(content, &conf); err != nil {
+		return nil, err
+	}
+
+	for name := range conf.Conventions {
+		if findRule(name) == nil {
+			return nil, fmt.Errorf("unknown conventions rule %q", name)
+		}
+	}
+	return conf.Conventions, nil
+}
+
+// IsEnabled returns whether the passed rule is enabled
+func (conf Configuration) IsEnabled(name string) bool {
+	enabled, ok := conf[name]
+	return !ok || enabled
+}
+
+// Check checks the passed types with the enabled rules, adding a
+// warning to the diagnostics for every violation
+func Check(kt parser.KubeTypes, conf Configuration, report *diagnostics.Diagnostics) {
+	// The fields promoted from inlined structures are part of each
+	// structure inlining them, but they must be checked only once
+	checked := make(map[parser.Position]bool)
+
+	for _, kubeStructure := range kt {
+		for _, rule := range Rules {
+			if rule.checkType == nil || !conf.IsEnabled(rule.Name) {
+				continue
+			}
+			if message := rule.checkType(kubeStructure); message != "" {
+				warn(report, kubeStructure.Position, rule.Name, message)
+			}
+		}
+
+		for _, field := range kubeStructure.Fields {
+			if field.Position.IsValid() {
+				if checked[field.Position] {
+					continue
+				}
+				checked[field.Position] = true
+			}
+
+			for _, rule := range Rules {
+				if rule.checkField == nil || !conf.IsEnabled(rule.Name) {
+					continue
+				}
+				if message := rule.checkField(kubeStructure, field); message != "" {
+					warn(report, field.Position, rule.Name, message)
+				}
+			}
+		}
+	}
+}
+
+func warn(report *diagnostics.Diagnostics, pos parser.Position, rule string, message string) {
+	report.Warnf(pos.Filename, pos.Line, pos.Column, rule, "%v", message)
+}
+
+func findRule(name string) *Rule {
+	for idx := range Rules {
+		if Rules[idx].Name == name {
+			return &Rules[idx]
+		}
+	}
+	return nil
+}
+
+func hasField(kubeStructure parser.KubeStructure, name string) bool {
+	for _, field := range kubeStructure.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isTopLevelField returns whether a field of a root Kind is one of the
+// standard ones, i.e. `metadata`, `spec` or `status`
+func isTopLevelField(name string) bool {
+	return name == "metadata" || name == "spec" || name == "status"
+}
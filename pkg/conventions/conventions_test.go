@@ -0,0 +1,136 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conventions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// namedType is a type named `name` declared in the passed package
+func namedType(packagePath string, name string) parser.TypeInfo {
+	return parser.TypeInfo{Name: name, BaseType: name, Package: packagePath, Kind: parser.TypeKindNamed}
+}
+
+func TestCheck(t *testing.T) {
+	condition := namedType("k8s.io/apimachinery/pkg/apis/meta/v1", "metav1.Condition")
+	tests := []struct {
+		name     string
+		field    parser.KubeField
+		root     bool
+		conf     Configuration
+		expected []string
+	}{
+		{
+			name:  "mandatory field",
+			field: parser.KubeField{Name: "size", Type: namedType("", "string"), Mandatory: true},
+		},
+		{
+			name:     "not camelCase",
+			field:    parser.KubeField{Name: "Size_mb", Type: namedType("", "string"), Mandatory: true},
+			expected: []string{"json-camel-case"},
+		},
+		{
+			name:     "optional value",
+			field:    parser.KubeField{Name: "size", Type: namedType("", "string")},
+			expected: []string{"optional-pointer", "optional-omitempty"},
+		},
+		{
+			name: "optional pointer",
+			field: parser.KubeField{
+				Name:      "size",
+				OmitEmpty: true,
+				Type:      parser.TypeInfo{Name: "*string", Kind: parser.TypeKindPointer, Elem: &parser.TypeInfo{Name: "string"}},
+			},
+		},
+		{
+			name:  "standard field of a root Kind",
+			field: parser.KubeField{Name: "spec", Type: namedType("example.com/api/v1", "ClusterSpec"), OmitEmpty: true},
+			root:  true,
+		},
+		{
+			name: "conditions",
+			field: parser.KubeField{
+				Name:      "conditions",
+				OmitEmpty: true,
+				Type:      parser.TypeInfo{Name: "[]metav1.Condition", Kind: parser.TypeKindSlice, Elem: &condition},
+			},
+		},
+		{
+			name: "conditions of another type",
+			field: parser.KubeField{
+				Name:      "conditions",
+				Mandatory: true,
+				Type: parser.TypeInfo{Name: "[]string", Kind: parser.TypeKindSlice, Elem: &parser.TypeInfo{
+					Name: "string", BaseType: "string", Kind: parser.TypeKindNamed,
+				}},
+			},
+			expected: []string{"conditions-type"},
+		},
+		{
+			name:     "boolean",
+			field:    parser.KubeField{Name: "enabled", Type: namedType("", "bool"), Mandatory: true},
+			expected: []string{"bool-field"},
+		},
+		{
+			name:  "disabled rule",
+			field: parser.KubeField{Name: "enabled", Type: namedType("", "bool"), Mandatory: true},
+			conf:  Configuration{"bool-field": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeStructure := parser.KubeStructure{Name: "Cluster", Fields: []parser.KubeField{tt.field}}
+			if tt.root {
+				kubeStructure.Resource = &parser.KubeResource{Kind: "Cluster"}
+				kubeStructure.Fields = append(kubeStructure.Fields,
+					parser.KubeField{Name: "status", Type: namedType("example.com/api/v1", "ClusterStatus"), Mandatory: true})
+			}
+
+			var report diagnostics.Diagnostics
+			Check(parser.KubeTypes{kubeStructure}, tt.conf, &report)
+
+			var rules []string
+			for _, diagnostic := range report {
+				rules = append(rules, diagnostic.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.expected) {
+				t.Errorf("expected the rules %v, got %v", tt.expected, rules)
+			}
+		})
+	}
+}
+
+func TestRootSpecStatus(t *testing.T) {
+	kubeStructure := parser.KubeStructure{
+		Name:     "Cluster",
+		Resource: &parser.KubeResource{Kind: "Cluster"},
+		Fields: []parser.KubeField{
+			{Name: "spec", Type: namedType("example.com/api/v1", "ClusterSpec"), Mandatory: true},
+		},
+	}
+
+	var report diagnostics.Diagnostics
+	Check(parser.KubeTypes{kubeStructure}, nil, &report)
+	if len(report) != 1 || report[0].Message != "root Kind Cluster has no status field" {
+		t.Errorf("expected the status field to be reported as missing, got %v", report)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diagnostics contain the problems found while reading and
+// checking the API definitions
+package diagnostics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity is the severity of a diagnostic
+type Severity string
+
+const (
+	// SeverityError is a problem preventing the documentation to be
+	// generated correctly
+	SeverityError = Severity("error")
+
+	// SeverityWarning is a problem which should be fixed, but doesn't
+	// prevent the documentation to be generated
+	SeverityWarning = Severity("warning")
+)
+
+// Diagnostic is a problem found at a certain position of the source code
+type Diagnostic struct {
+	// How bad the problem is
+	Severity Severity
+
+	// The file path, empty if the problem is not related to a file
+	Filename string
+
+	// The line and column numbers, starting at 1, or 0 if not known
+	Line   int
+	Column int
+
+	// The identifier of the check which found the problem,
+	// i.e. `unsupported-type`
+	Rule string
+
+	// The description of the problem
+	Message string
+}
+
+// String formats the diagnostic as the Go compiler does,
+// i.e. `api/v1/cluster_types.go:12:2: warning: message [rule]`
+func (d Diagnostic) String() string {
+	var result strings.Builder
+	if d.Filename != "" {
+		result.WriteString(d.Filename)
+		if d.Line > 0 {
+			fmt.Fprintf(&result, ":%v", d.Line)
+			if d.Column > 0 {
+				fmt.Fprintf(&result, ":%v", d.Column)
+			}
+		}
+		result.WriteString(": ")
+	}
+	fmt.Fprintf(&result, "%v: %v", d.Severity, d.Message)
+	if d.Rule != "" {
+		fmt.Fprintf(&result, " [%v]", d.Rule)
+	}
+	return result.String()
+}
+
+// Diagnostics is a list of problems
+type Diagnostics []Diagnostic
+
+// Errorf adds an error to the list
+func (d *Diagnostics) Errorf(filename string, line, column int, rule string, format string, args ...interface{}) {
+	d.add(SeverityError, filename, line, column, rule, fmt.Sprintf(format, args...))
+}
+
+// Warnf adds a warning to the list
+func (d *Diagnostics) Warnf(filename string, line, column int, rule string, format string, args ...interface{}) {
+	d.add(SeverityWarning, filename, line, column, rule, fmt.Sprintf(format, args...))
+}
+
+func (d *Diagnostics) add(severity Severity, filename string, line, column int, rule string, message string) {
+	*d = append(*d, Diagnostic{
+		Severity: severity,
+		Filename: filename,
+		Line:     line,
+		Column:   column,
+		Rule:     rule,
+		Message:  message,
+	})
+}
+
+// Count returns the number of diagnostics with the given severity
+func (d Diagnostics) Count(severity Severity) int {
+	count := 0
+	for _, diagnostic := range d {
+		if diagnostic.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// HasErrors returns whether the list contains at least an error
+func (d Diagnostics) HasErrors() bool {
+	return d.Count(SeverityError) > 0
+}
+
+// Sort sorts the diagnostics by position, keeping the order in
+// which they have been found for the ones at the same position
+func (d Diagnostics) Sort() {
+	sort.SliceStable(d, func(i, j int) bool {
+		if d[i].Filename != d[j].Filename {
+			return d[i].Filename < d[j].Filename
+		}
+		if d[i].Line != d[j].Line {
+			return d[i].Line < d[j].Line
+		}
+		return d[i].Column < d[j].Column
+	})
+}
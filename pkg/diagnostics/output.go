@@ -0,0 +1,207 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+)
+
+// ErrorWrongFormat means that the user specified a diagnostics format which we don't support
+var ErrorWrongFormat = fmt.Errorf("wrong diagnostics format")
+
+// Format is an output format for the diagnostics
+type Format string
+
+const (
+	// FormatText is the format used by the Go compiler, one diagnostic per line
+	FormatText = Format("text")
+
+	// FormatJSON is a JSON array of diagnostics
+	FormatJSON = Format("json")
+
+	// FormatSARIF is the Static Analysis Results Interchange Format 2.1.0,
+	// which is understood by code scanning tools
+	FormatSARIF = Format("sarif")
+)
+
+// jsonDiagnostic is the JSON representation of a diagnostic
+type jsonDiagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// The subset of SARIF we use, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// Write writes the diagnostics in the given format
+func Write(w io.Writer, d Diagnostics, format Format) error {
+	switch format {
+	case FormatText:
+		for _, diagnostic := range d {
+			if _, err := fmt.Fprintln(w, diagnostic.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatJSON:
+		result := make([]jsonDiagnostic, 0, len(d))
+		for _, diagnostic := range d {
+			result = append(result, jsonDiagnostic{
+				Severity: diagnostic.Severity,
+				File:     filepath.ToSlash(diagnostic.Filename),
+				Line:     diagnostic.Line,
+				Column:   diagnostic.Column,
+				Rule:     diagnostic.Rule,
+				Message:  diagnostic.Message,
+			})
+		}
+		return writeJSON(w, result)
+
+	case FormatSARIF:
+		return writeJSON(w, toSARIF(d))
+
+	default:
+		return ErrorWrongFormat
+	}
+}
+
+func toSARIF(d Diagnostics) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "k8s-api-docgen",
+				InformationURI: "https://github.com/EnterpriseDB/k8s-api-docgen",
+			},
+		},
+		Results: make([]sarifResult, 0, len(d)),
+	}
+
+	knownRules := make(map[string]bool)
+	for _, diagnostic := range d {
+		if diagnostic.Rule != "" && !knownRules[diagnostic.Rule] {
+			knownRules[diagnostic.Rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: diagnostic.Rule})
+		}
+
+		result := sarifResult{
+			RuleID:  diagnostic.Rule,
+			Level:   string(diagnostic.Severity),
+			Message: sarifMessage{Text: diagnostic.Message},
+		}
+		if diagnostic.Filename != "" {
+			location := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(diagnostic.Filename)},
+				},
+			}
+			if diagnostic.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   diagnostic.Line,
+					StartColumn: diagnostic.Column,
+				}
+			}
+			result.Locations = append(result.Locations, location)
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// sarifURI converts a file path to the URI referring to it, which is
+// relative when the path is relative
+func sarifURI(fileName string) string {
+	if filepath.IsAbs(fileName) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(fileName)}).String()
+	}
+	return (&url.URL{Path: filepath.ToSlash(fileName)}).String()
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	j, err := json.MarshalIndent(value, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(j))
+	return err
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package doctree contain the structure of the doc comments, parsed with
+// the Go doc comment syntax, which is emitted by each renderer in its own
+// format. See https://go.dev/doc/comment
+package doctree
+
+import (
+	"go/doc/comment"
+	"regexp"
+	"strings"
+)
+
+// BlockKind is the kind of a block of a document
+type BlockKind string
+
+const (
+	// BlockParagraph is a paragraph of text
+	BlockParagraph = BlockKind("paragraph")
+
+	// BlockHeading is a section heading, i.e. `# Examples`
+	BlockHeading = BlockKind("heading")
+
+	// BlockList is a bulleted or numbered list
+	BlockList = BlockKind("list")
+
+	// BlockCode is a preformatted block, written indented in the comment
+	BlockCode = BlockKind("code")
+)
+
+// InlineKind is the kind of a span of text
+type InlineKind string
+
+const (
+	// InlineText is plain text
+	InlineText = InlineKind("text")
+
+	// InlineLink is a link to a URL, written as is or as `[text]` with
+	// the URL declared in a `[text]: URL` line
+	InlineLink = InlineKind("link")
+
+	// InlineDocLink is a link to a Go declaration, i.e. `[Cluster]`
+	// or `[corev1.Pod]`
+	InlineDocLink = InlineKind("docLink")
+)
+
+// Document is a parsed doc comment
+type Document struct {
+	Blocks []Block
+}
+
+// Block is a paragraph, a heading, a list or a code block
+type Block struct {
+	Kind BlockKind
+
+	// The text of a paragraph or of a heading
+	Text []Inline
+
+	// The items of a list
+	Items []ListItem
+
+	// True if the list items are numbered
+	Ordered bool
+
+	// The content of a code block, without the indentation
+	Code string
+}
+
+// ListItem is an item of a list
+type ListItem struct {
+	// The number of the item of a numbered list, i.e. `1`
+	Number string
+
+	// The content of the item
+	Blocks []Block
+}
+
+// Inline is a span of text
+type Inline struct {
+	Kind InlineKind
+
+	// The text, which is the text shown for the links
+	Text string
+
+	// The target of a link
+	URL string
+
+	// The target of a doc link: the import path of the package, empty
+	// when the declaration is in the same package, the type name, if
+	// the target is a method or a field, and the declaration name
+	ImportPath string
+	Recv       string
+	Name       string
+}
+
+// IsEmpty returns whether the document has no content
+func (d Document) IsEmpty() bool {
+	return len(d.Blocks) == 0
+}
+
+// Parser parses the doc comments of the elements declared in a package
+type Parser struct {
+	// The import path of the package, used for the doc links which
+	// don't specify one
+	PackagePath string
+
+	// LookupPackage resolves the name of an imported package, i.e. `corev1`,
+	// to its import path. When nil only the standard library packages are
+	// resolved
+	LookupPackage func(name string) (importPath string, ok bool)
+}
+
+// Parse parses the text of a doc comment, without the comment markers.
+// The markers and the TODOs are expected to be already removed. Every
+// bracketed identifier, i.e. `[Cluster]` or `[Cluster.Spec]`, is considered
+// a doc link, as the declarations they refer to can belong to any of the
+// documented packages. As the JSON names of the fields are not capitalized,
+// `[Cluster.spec]` is considered a doc link too.
+func (p Parser) Parse(text string) Document {
+	parser := comment.Parser{
+		LookupPackage: p.LookupPackage,
+		LookupSym: func(recv, name string) bool {
+			return true
+		},
+	}
+	document := Document{Blocks: convertBlocks(parser.Parse(text).Content)}
+	document.walkText(func(text []Inline) []Inline {
+		text = splitFieldLinks(text)
+		for idx := range text {
+			if text[idx].Kind == InlineDocLink && text[idx].ImportPath == "" {
+				text[idx].ImportPath = p.PackagePath
+			}
+		}
+		return text
+	})
+	return document
+}
+
+// Parse parses the text of a doc comment, resolving only the doc links
+// to the standard library packages
+func Parse(text string) Document {
+	return Parser{}.Parse(text)
+}
+
+// walkText calls the passed function on the text of every paragraph and
+// heading, replacing it with the returned one
+func (d Document) walkText(fn func(text []Inline) []Inline) {
+	walkBlocks(d.Blocks, fn)
+}
+
+func walkBlocks(blocks []Block, fn func(text []Inline) []Inline) {
+	for idx := range blocks {
+		if blocks[idx].Text != nil {
+			blocks[idx].Text = fn(blocks[idx].Text)
+		}
+		for _, item := range blocks[idx].Items {
+			walkBlocks(item.Blocks, fn)
+		}
+	}
+}
+
+// fieldLinkRegexp matches a reference to a field using its JSON name,
+// i.e. `[Cluster.spec]`
+var fieldLinkRegexp = regexp.MustCompile(`\[([A-Za-z_][A-Za-z0-9_]*)\.([a-z_][A-Za-z0-9_]*)\]`)
+
+// splitFieldLinks converts the references to fields using their JSON name,
+// which the Go syntax doesn't consider doc links, to doc links
+func splitFieldLinks(text []Inline) []Inline {
+	var result []Inline
+	for _, span := range text {
+		if span.Kind != InlineText {
+			result = append(result, span)
+			continue
+		}
+
+		last := 0
+		for _, match := range fieldLinkRegexp.FindAllStringSubmatchIndex(span.Text, -1) {
+			if match[0] > last {
+				result = append(result, Inline{Kind: InlineText, Text: span.Text[last:match[0]]})
+			}
+			result = append(result, Inline{
+				Kind: InlineDocLink,
+				Text: span.Text[match[0]+1 : match[1]-1],
+				Recv: span.Text[match[2]:match[3]],
+				Name: span.Text[match[4]:match[5]],
+			})
+			last = match[1]
+		}
+		if last < len(span.Text) {
+			result = append(result, Inline{Kind: InlineText, Text: span.Text[last:]})
+		}
+	}
+	return result
+}
+
+func convertBlocks(blocks []comment.Block) []Block {
+	var result []Block
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case *comment.Paragraph:
+			result = append(result, Block{Kind: BlockParagraph, Text: convertText(b.Text)})
+
+		case *comment.Heading:
+			result = append(result, Block{Kind: BlockHeading, Text: convertText(b.Text)})
+
+		case *comment.Code:
+			result = append(result, Block{Kind: BlockCode, Code: strings.TrimRight(b.Text, "\n")})
+
+		case *comment.List:
+			list := Block{Kind: BlockList}
+			for _, item := range b.Items {
+				list.Items = append(list.Items, ListItem{
+					Number: item.Number,
+					Blocks: convertBlocks(item.Content),
+				})
+				list.Ordered = item.Number != ""
+			}
+			result = append(result, list)
+		}
+	}
+	return result
+}
+
+func convertText(text []comment.Text) []Inline {
+	var result []Inline
+	appendText := func(s string) {
+		// Adjacent plain text spans are merged
+		if len(result) > 0 && result[len(result)-1].Kind == InlineText {
+			result[len(result)-1].Text += s
+			return
+		}
+		result = append(result, Inline{Kind: InlineText, Text: s})
+	}
+
+	for _, span := range text {
+		switch t := span.(type) {
+		case comment.Plain:
+			appendText(string(t))
+
+		case comment.Italic:
+			appendText(string(t))
+
+		case *comment.Link:
+			result = append(result, Inline{Kind: InlineLink, Text: PlainText(convertText(t.Text)), URL: t.URL})
+
+		case *comment.DocLink:
+			result = append(result, Inline{
+				Kind:       InlineDocLink,
+				Text:       PlainText(convertText(t.Text)),
+				ImportPath: t.ImportPath,
+				Recv:       t.Recv,
+				Name:       t.Name,
+			})
+		}
+	}
+	return result
+}
+
+// PlainText returns the text of a list of spans, without the links
+func PlainText(text []Inline) string {
+	var result strings.Builder
+	for _, span := range text {
+		result.WriteString(span.Text)
+	}
+	return result.String()
+}
@@ -0,0 +1,441 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package drift contain the code checking that the CustomResourceDefinition
+// manifests agree with the Go types they have been generated from
+package drift
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// rule is the identifier of the diagnostics reported by this package
+const rule = "crd-drift"
+
+// goBuiltinTypes are the OpenAPI types of the Go builtin types
+var goBuiltinTypes = map[string]string{
+	"string":  "string",
+	"bool":    "boolean",
+	"int":     "integer",
+	"int8":    "integer",
+	"int16":   "integer",
+	"int32":   "integer",
+	"int64":   "integer",
+	"uint":    "integer",
+	"uint8":   "integer",
+	"uint16":  "integer",
+	"uint32":  "integer",
+	"uint64":  "integer",
+	"byte":    "integer",
+	"rune":    "integer",
+	"float32": "number",
+	"float64": "number",
+}
+
+// crdBuiltinTypes are the OpenAPI types used in the schemas
+var crdBuiltinTypes = map[string]string{
+	"string":  "string",
+	"boolean": "boolean",
+	"integer": "integer",
+	"number":  "number",
+	"object":  "object",
+}
+
+// externalTypes are the OpenAPI types of the well-known external types,
+// indexed by qualified name. The ones not listed here are not compared
+var externalTypes = map[string]string{
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Time":          "string",
+	"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime":     "string",
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Duration":      "string",
+	"k8s.io/apimachinery/pkg/util/intstr.IntOrString":    "int-or-string",
+	"k8s.io/apimachinery/pkg/api/resource.Quantity":      "int-or-string",
+	"k8s.io/apimachinery/pkg/runtime.RawExtension":       "object",
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Condition":     "object",
+	"k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector": "object",
+}
+
+// inheritedFields are the fields of the well-known external types which
+// are inlined, indexed by qualified name. The fields of the other inlined
+// external types are not known
+var inheritedFields = map[string][]string{
+	"k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta": {"apiVersion", "kind"},
+}
+
+// schemaType is the OpenAPI type of a field, as described by a Go type
+// or by a CRD schema
+type schemaType struct {
+	// The OpenAPI type, i.e. `string`, `array` or `object`, plus `map`
+	// and `int-or-string`. Empty when the type is not known, in which
+	// case it is not compared
+	Type string
+
+	// The type of the items of an array or of the values of a map
+	Items *schemaType
+
+	// The structure describing an object, if known
+	Structure *parser.KubeStructure
+}
+
+// String describes the type, i.e. `array of string`
+func (t schemaType) String() string {
+	switch t.Type {
+	case "array", "map":
+		return fmt.Sprintf("%v of %v", t.Type, t.Items.String())
+	case "":
+		return "unknown type"
+	default:
+		return t.Type
+	}
+}
+
+// checker compares two sets of types
+type checker struct {
+	goTypes  map[string]*parser.KubeStructure
+	crdTypes map[string]*parser.KubeStructure
+	report   *diagnostics.Diagnostics
+
+	// The pairs of structures already compared, used to stop
+	// on recursive types
+	compared map[[2]*parser.KubeStructure]bool
+}
+
+// Check compares the Kinds declared by the Go types with the ones declared by
+// the CustomResourceDefinitions, adding an error to the diagnostics for each
+// Kind or field which is missing on one side and for each field whose type,
+// required-ness or allowed values differ. Only the Kinds of the API versions
+// described by both sides are compared. The Go types must include the ones
+// hidden from the documentation, which are part of the CRDs too. The fields
+// of a CRD are not checked for presence in the Go types when they can come
+// from an inlined external type whose fields are not known.
+func Check(goTypes parser.KubeTypes, crdTypes parser.KubeTypes, report *diagnostics.Diagnostics) {
+	c := checker{
+		goTypes:  index(goTypes),
+		crdTypes: index(crdTypes),
+		report:   report,
+		compared: make(map[[2]*parser.KubeStructure]bool),
+	}
+
+	goKinds := rootKinds(goTypes)
+	crdKinds := rootKinds(crdTypes)
+
+	goVersions := make(map[parser.GroupVersion]bool)
+	for key := range goKinds {
+		goVersions[key.GroupVersion] = true
+	}
+	crdVersions := make(map[parser.GroupVersion]bool)
+	for key := range crdKinds {
+		crdVersions[key.GroupVersion] = true
+	}
+
+	for _, key := range sortedKeys(goKinds) {
+		goKind := goKinds[key]
+		crdKind, ok := crdKinds[key]
+		switch {
+		case ok:
+			c.compareStructures(goKind, crdKind, goKind.Name)
+		case crdVersions[key.GroupVersion]:
+			c.errorf(goKind.Position, "Kind %v of %v has no CRD", key.Kind, key.GroupVersion.String())
+		}
+	}
+
+	for _, key := range sortedKeys(crdKinds) {
+		crdKind := crdKinds[key]
+		if _, ok := goKinds[key]; !ok && goVersions[key.GroupVersion] {
+			c.errorf(crdKind.Position, "Kind %v of %v is in the CRD but not in the Go types",
+				key.Kind, key.GroupVersion.String())
+		}
+	}
+}
+
+// compareStructures compares the fields of two structures describing
+// the same object. The path is used to name the fields in the messages
+func (c *checker) compareStructures(goStructure, crdStructure *parser.KubeStructure, path string) {
+	pair := [2]*parser.KubeStructure{goStructure, crdStructure}
+	if c.compared[pair] {
+		return
+	}
+	c.compared[pair] = true
+
+	crdFields := make(map[string]parser.KubeField)
+	for _, field := range crdStructure.Fields {
+		crdFields[field.Name] = field
+	}
+
+	goFields := make(map[string]bool)
+	for _, goField := range goStructure.Fields {
+		goFields[goField.Name] = true
+		name := path + "." + goField.Name
+
+		crdField, ok := crdFields[goField.Name]
+		if !ok {
+			c.errorf(goField.Position, "field %v is in the Go types but not in the CRD %v",
+				name, crdStructure.Position.Filename)
+			continue
+		}
+
+		if goField.Mandatory != crdField.Mandatory {
+			c.errorf(goField.Position, "field %v is %v in the Go types and %v in the CRD %v",
+				name, requiredness(goField.Mandatory), requiredness(crdField.Mandatory),
+				crdStructure.Position.Filename)
+		}
+
+		goType := c.goFieldType(goField)
+		crdType := c.crdType(crdField.Type)
+		c.compareTypes(goField, goType, crdType, name, crdStructure.Position.Filename)
+
+		goEnum := c.goEnum(goField)
+		if !sameValues(goEnum, crdField.Validations.Enum) {
+			c.errorf(goField.Position, "field %v allows %v in the Go types and %v in the CRD %v",
+				name, describeEnum(goEnum), describeEnum(crdField.Validations.Enum),
+				crdStructure.Position.Filename)
+		}
+	}
+
+	// The fields inherited from external types are part of the CRD too,
+	// but only the ones of the well-known types can be checked
+	for _, inherits := range [][]parser.TypeInfo{goStructure.Inherits, goStructure.HiddenInherits} {
+		for _, inherited := range inherits {
+			names, known := inheritedFields[inherited.QualifiedName()]
+			if !known {
+				return
+			}
+			for _, name := range names {
+				goFields[name] = true
+			}
+		}
+	}
+
+	for _, crdField := range crdStructure.Fields {
+		if !goFields[crdField.Name] {
+			c.errorf(goStructure.Position, "field %v.%v is in the CRD %v but not in the Go types",
+				path, crdField.Name, crdStructure.Position.Filename)
+		}
+	}
+}
+
+// compareTypes compares the type of a field, descending into the
+// items of arrays and maps and into the nested objects
+func (c *checker) compareTypes(
+	goField parser.KubeField,
+	goType, crdType schemaType,
+	path string,
+	crdFile string,
+) {
+	if goType.Type == "" || crdType.Type == "" {
+		return
+	}
+	if goType.Type != crdType.Type {
+		c.errorf(goField.Position, "field %v has type %v in the Go types and %v in the CRD %v",
+			path, goType.String(), crdType.String(), crdFile)
+		return
+	}
+
+	switch {
+	case goType.Items != nil && crdType.Items != nil:
+		c.compareTypes(goField, *goType.Items, *crdType.Items, path, crdFile)
+	case goType.Structure != nil && crdType.Structure != nil:
+		c.compareStructures(goType.Structure, crdType.Structure, path)
+	}
+}
+
+// goFieldType returns the OpenAPI type of a Go field, which can be
+// overridden with the `+kubebuilder:validation:Type` marker
+func (c *checker) goFieldType(field parser.KubeField) schemaType {
+	if field.Validations.Type != "" {
+		return schemaType{Type: field.Validations.Type}
+	}
+	return c.goType(field.Type, 0)
+}
+
+// goType returns the OpenAPI type of a Go type. The depth is used to
+// stop on recursive named types
+func (c *checker) goType(info parser.TypeInfo, depth int) schemaType {
+	if depth > 10 {
+		return schemaType{}
+	}
+
+	switch info.Kind {
+	case parser.TypeKindPointer:
+		return c.goType(*info.Elem, depth)
+
+	case parser.TypeKindSlice, parser.TypeKindArray:
+		if info.Elem.Kind == parser.TypeKindNamed && info.Elem.QualifiedName() == "byte" {
+			// Byte slices are serialized as base64 strings
+			return schemaType{Type: "string"}
+		}
+		items := c.goType(*info.Elem, depth)
+		return schemaType{Type: "array", Items: &items}
+
+	case parser.TypeKindMap:
+		items := c.goType(*info.Elem, depth)
+		return schemaType{Type: "map", Items: &items}
+
+	case parser.TypeKindNamed, parser.TypeKindStruct:
+		if len(info.TypeArgs) > 0 {
+			return schemaType{}
+		}
+		if openAPIType, ok := goBuiltinTypes[info.QualifiedName()]; ok {
+			return schemaType{Type: openAPIType}
+		}
+		if openAPIType, ok := externalTypes[info.QualifiedName()]; ok {
+			return schemaType{Type: openAPIType}
+		}
+
+		kubeStructure, ok := c.goTypes[info.QualifiedName()]
+		switch {
+		case !ok:
+			return schemaType{}
+		case kubeStructure.Underlying != nil:
+			return c.goType(*kubeStructure.Underlying, depth+1)
+		default:
+			return schemaType{Type: "object", Structure: kubeStructure}
+		}
+
+	default:
+		return schemaType{}
+	}
+}
+
+// crdType returns the OpenAPI type of a type built from a CRD schema
+func (c *checker) crdType(info parser.TypeInfo) schemaType {
+	switch info.Kind {
+	case parser.TypeKindSlice:
+		items := c.crdType(*info.Elem)
+		return schemaType{Type: "array", Items: &items}
+
+	case parser.TypeKindMap:
+		items := c.crdType(*info.Elem)
+		return schemaType{Type: "map", Items: &items}
+
+	case parser.TypeKindNamed:
+		if openAPIType, ok := externalTypes[info.QualifiedName()]; ok {
+			return schemaType{Type: openAPIType}
+		}
+		if info.Package == "" {
+			return schemaType{Type: crdBuiltinTypes[info.Name]}
+		}
+		if kubeStructure, ok := c.crdTypes[info.QualifiedName()]; ok {
+			return schemaType{Type: "object", Structure: kubeStructure}
+		}
+		return schemaType{}
+
+	default:
+		return schemaType{}
+	}
+}
+
+// goEnum returns the values allowed for a Go field, which can be declared
+// on the field or on its type
+func (c *checker) goEnum(field parser.KubeField) []string {
+	if len(field.Validations.Enum) > 0 {
+		return field.Validations.Enum
+	}
+
+	info := field.Type
+	if info.Kind == parser.TypeKindPointer {
+		info = *info.Elem
+	}
+	if info.Kind != parser.TypeKindNamed {
+		return nil
+	}
+	if kubeStructure, ok := c.goTypes[info.QualifiedName()]; ok {
+		return kubeStructure.Validations.Enum
+	}
+	return nil
+}
+
+func (c *checker) errorf(pos parser.Position, format string, args ...interface{}) {
+	c.report.Errorf(pos.Filename, pos.Line, pos.Column, rule, format, args...)
+}
+
+// kindKey identifies a Kind
+type kindKey struct {
+	GroupVersion parser.GroupVersion
+	Kind         string
+}
+
+// rootKinds returns the Kinds declared in a set of types, excluding the Lists
+func rootKinds(kt parser.KubeTypes) map[kindKey]*parser.KubeStructure {
+	result := make(map[kindKey]*parser.KubeStructure)
+	for idx := range kt {
+		kubeStructure := &kt[idx]
+		if kubeStructure.Resource == nil || kubeStructure.Resource.List {
+			continue
+		}
+		result[kindKey{GroupVersion: kubeStructure.GroupVersion, Kind: kubeStructure.Resource.Kind}] = kubeStructure
+	}
+	return result
+}
+
+// index returns the types indexed by qualified name
+func index(kt parser.KubeTypes) map[string]*parser.KubeStructure {
+	result := make(map[string]*parser.KubeStructure)
+	for idx := range kt {
+		result[kt[idx].QualifiedName()] = &kt[idx]
+	}
+	return result
+}
+
+func sortedKeys(kinds map[kindKey]*parser.KubeStructure) []kindKey {
+	result := make([]kindKey, 0, len(kinds))
+	for key := range kinds {
+		result = append(result, key)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].GroupVersion.String() != result[j].GroupVersion.String() {
+			return result[i].GroupVersion.String() < result[j].GroupVersion.String()
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
+
+func requiredness(mandatory bool) string {
+	if mandatory {
+		return "required"
+	}
+	return "optional"
+}
+
+// sameValues returns whether two lists contain the same values,
+// regardless of their order
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for idx := range sortedA {
+		if sortedA[idx] != sortedB[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func describeEnum(values []string) string {
+	if len(values) == 0 {
+		return "any value"
+	}
+	return "`" + strings.Join(values, "`, `") + "`"
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// goSource is the source of the Go types, where the fields of the
+// spec are replaced
+const goSource = `// +groupName=example.com
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Cluster is a cluster
+// +kubebuilder:object:root=true
+type Cluster struct {
+	metav1.TypeMeta   ` + "`json:\",inline\"`" + `
+	metav1.ObjectMeta ` + "`json:\"metadata,omitempty\"`" + `
+
+	// Spec is the specification
+	Spec ClusterSpec ` + "`json:\"spec\"`" + `
+}
+
+// Phase is a phase
+// +kubebuilder:validation:Enum=ready;failed
+type Phase string
+
+// ClusterSpec is the specification
+type ClusterSpec struct {
+SPEC
+}
+
+var _ corev1.ResourceRequirements
+`
+
+// crdSource is the CRD generated from the Go types, where the
+// properties of the spec are replaced
+const crdSource = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusters.example.com
+spec:
+  group: example.com
+  names:
+    kind: Cluster
+    listKind: ClusterList
+    plural: clusters
+    singular: cluster
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        required:
+        - spec
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          spec:
+            type: object
+SPEC
+`
+
+// checkSources writes the passed sources and compares the types they declare
+func checkSources(t *testing.T, spec string, crdSpec string) diagnostics.Diagnostics {
+	t.Helper()
+
+	dir := t.TempDir()
+	goFile := filepath.Join(dir, "cluster_types.go")
+	crdFile := filepath.Join(dir, "cluster.yaml")
+	if err := os.WriteFile(goFile, []byte(strings.Replace(goSource, "SPEC", spec, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(crdFile, []byte(strings.Replace(crdSource, "SPEC", crdSpec, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	goTypes, report, err := parser.GetKubeTypes([]string{goFile}, nil)
+	if err != nil || report.HasErrors() {
+		t.Fatalf("cannot read the Go types: %v %v", err, report)
+	}
+	crdTypes, report, err := parser.GetKubeTypesFromCRDs([]string{crdFile})
+	if err != nil || report.HasErrors() {
+		t.Fatalf("cannot read the CRD: %v %v", err, report)
+	}
+
+	var result diagnostics.Diagnostics
+	Check(goTypes, crdTypes, &result)
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		crdSpec  string
+		expected []string
+	}{
+		{
+			name: "in sync",
+			spec: `	Size string ` + "`json:\"size\"`" + `
+	// +optional
+	Replicas *int32 ` + "`json:\"replicas,omitempty\"`" + `
+	Labels map[string]string ` + "`json:\"labels\"`" + ``,
+			crdSpec: `            required: [size, labels]
+            properties:
+              size:
+                type: string
+              replicas:
+                type: integer
+              labels:
+                type: object
+                additionalProperties:
+                  type: string`,
+		},
+		{
+			name: "missing fields",
+			spec: `	Size string ` + "`json:\"size\"`" + `
+	Extra string ` + "`json:\"extra\"`" + ``,
+			crdSpec: `            required: [size]
+            properties:
+              size:
+                type: string
+              stale:
+                type: string`,
+			expected: []string{
+				"field Cluster.spec.extra is in the Go types but not in the CRD",
+				"field Cluster.spec.stale is in the CRD",
+			},
+		},
+		{
+			name: "different types and required-ness",
+			spec: `	Size int32 ` + "`json:\"size\"`" + `
+	// +optional
+	Name string ` + "`json:\"name\"`" + ``,
+			crdSpec: `            required: [size, name]
+            properties:
+              size:
+                type: string
+              name:
+                type: string`,
+			expected: []string{
+				"field Cluster.spec.size has type integer in the Go types and string in the CRD",
+				"field Cluster.spec.name is optional in the Go types and required in the CRD",
+			},
+		},
+		{
+			name: "different allowed values",
+			spec: `	Phase Phase ` + "`json:\"phase\"`" + ``,
+			crdSpec: `            required: [phase]
+            properties:
+              phase:
+                type: string
+                enum: [ready]`,
+			expected: []string{
+				"field Cluster.spec.phase allows",
+			},
+		},
+		{
+			name: "hidden fields are compared",
+			spec: `	Size string ` + "`json:\"size\"`" + `
+	// +docgen:hide
+	Internal string ` + "`json:\"internal\"`" + ``,
+			crdSpec: `            required: [size, internal]
+            properties:
+              size:
+                type: string
+              internal:
+                type: string`,
+		},
+		{
+			name: "fields of unknown inlined external types",
+			spec: `	corev1.ResourceRequirements ` + "`json:\",inline\"`" + `
+	Size string ` + "`json:\"size\"`" + ``,
+			crdSpec: `            required: [size]
+            properties:
+              size:
+                type: string
+              limits:
+                type: object
+                additionalProperties:
+                  type: string`,
+		},
+		{
+			name: "fields of hidden inlined external types",
+			spec: `	// +docgen:hide
+	corev1.ResourceRequirements ` + "`json:\",inline\"`" + `
+	Size string ` + "`json:\"size\"`" + ``,
+			crdSpec: `            required: [size]
+            properties:
+              size:
+                type: string
+              requests:
+                type: object`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := checkSources(t, tt.spec, tt.crdSpec)
+			if len(report) != len(tt.expected) {
+				t.Fatalf("expected %v problems, got %v", len(tt.expected), report)
+			}
+			for idx, expected := range tt.expected {
+				if !strings.Contains(report[idx].Message, expected) {
+					t.Errorf("expected a problem containing %q, got %q", expected, report[idx].Message)
+				}
+				if report[idx].Rule != rule {
+					t.Errorf("expected rule %q, got %q", rule, report[idx].Rule)
+				}
+			}
+		})
+	}
+}
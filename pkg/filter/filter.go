@@ -0,0 +1,287 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package filter contain the code selecting the types to be documented
+package filter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// regexpPrefix marks the patterns which are regular expressions
+const regexpPrefix = "re:"
+
+// Rule selects the types matching every pattern it declares. The patterns
+// are globs, where `*` matches any sequence of characters and `?` a single
+// character, or regular expressions when prefixed with "re:"
+type Rule struct {
+	// The pattern of the type name, i.e. `*List`
+	Name string `yaml:"name,omitempty"`
+
+	// The pattern of the API group and version, i.e. `*.io/v1`
+	GroupVersion string `yaml:"groupVersion,omitempty"`
+
+	// The pattern of the category declared via `+docgen:category`
+	Category string `yaml:"category,omitempty"`
+}
+
+// Filter selects the types to be documented. When there are include rules,
+// only the types matching one of them, and the types they refer to, are
+// documented. The types matching an exclude rule are never documented.
+type Filter struct {
+	Include []Rule `yaml:"include,omitempty"`
+	Exclude []Rule `yaml:"exclude,omitempty"`
+}
+
+// configuration is the part of the YAML configuration file we use
+type configuration struct {
+	Filters Filter `yaml:"filters,omitempty"`
+}
+
+// ruleKeyRegexp matches the beginning of an attribute of a rule
+// expressed on the command line
+var ruleKeyRegexp = regexp.MustCompile(`(?i)(?:^|,)(name|groupVersion|category)=`)
+
+// ParseRule parses a rule expressed on the command line in the form
+// `name=Cluster*,groupVersion=*/v1,category=core`. A value without any
+// attribute name, like `Cluster*`, is a pattern of the type name.
+func ParseRule(value string) Rule {
+	matches := ruleKeyRegexp.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 || matches[0][0] != 0 {
+		return Rule{Name: value}
+	}
+
+	var rule Rule
+	for idx, match := range matches {
+		end := len(value)
+		if idx+1 < len(matches) {
+			end = matches[idx+1][0]
+		}
+		pattern := value[match[1]:end]
+
+		switch strings.ToLower(value[match[2]:match[3]]) {
+		case "name":
+			rule.Name = pattern
+		case "groupversion":
+			rule.GroupVersion = pattern
+		case "category":
+			rule.Category = pattern
+		}
+	}
+	return rule
+}
+
+// LoadConfiguration reads the filters declared in the `filters` section
+// of a YAML configuration file
+func LoadConfiguration(fileName string) (Filter, error) {
+	content, err := os.ReadFile(fileName) // #nosec
+	if err != nil {
+		return Filter{}, err
+	}
+
+	var conf configuration
+	if err = yaml.Unmarshal(content, &conf); err != nil {
+		return Filter{}, err
+	}
+	return conf.Filters, nil
+}
+
+// IsEmpty returns whether the filter has no rules
+func (f Filter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Merge returns a filter with the rules of both filters
+func (f Filter) Merge(other Filter) Filter {
+	return Filter{
+		Include: append(append([]Rule(nil), f.Include...), other.Include...),
+		Exclude: append(append([]Rule(nil), f.Exclude...), other.Exclude...),
+	}
+}
+
+// Apply returns the types selected by the filter, in their original order.
+// The types which are only used by types not selected by the filter are
+// dropped too, as they are unreachable in the documentation.
+func (f Filter) Apply(kt parser.KubeTypes) (parser.KubeTypes, error) {
+	if f.IsEmpty() {
+		return kt, nil
+	}
+
+	include, err := compileRules(f.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileRules(f.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]int, len(kt))
+	excluded := make([]bool, len(kt))
+	for idx, kubeStructure := range kt {
+		byName[kubeStructure.QualifiedName()] = idx
+		excluded[idx] = exclude.match(kubeStructure)
+	}
+
+	references := func(idx int) []int {
+		var result []int
+		for _, reference := range kt[idx].References() {
+			if referenced, ok := byName[reference.QualifiedName()]; ok && referenced != idx {
+				result = append(result, referenced)
+			}
+		}
+		return result
+	}
+
+	// The documentation starts from the included types or, when there
+	// are no include rules, from the root Kinds and the types which can
+	// be reached without passing through an excluded one
+	var roots []int
+	if len(include) > 0 {
+		for idx, kubeStructure := range kt {
+			if !excluded[idx] && include.match(kubeStructure) {
+				roots = append(roots, idx)
+			}
+		}
+	} else {
+		var excludedRoots []int
+		for idx := range kt {
+			if excluded[idx] {
+				excludedRoots = append(excludedRoots, idx)
+			}
+		}
+		reachableFromExcluded := visit(excludedRoots, references, func(int) bool { return true })
+		for idx, kubeStructure := range kt {
+			if !excluded[idx] && (kubeStructure.Resource != nil || !reachableFromExcluded[idx]) {
+				roots = append(roots, idx)
+			}
+		}
+	}
+
+	selected := visit(roots, references, func(idx int) bool { return !excluded[idx] })
+
+	var result parser.KubeTypes
+	for idx, kubeStructure := range kt {
+		if selected[idx] {
+			result = append(result, kubeStructure)
+		}
+	}
+	return result, nil
+}
+
+// visit returns the types reachable from the roots, following the
+// references to the types accepted by the passed function
+func visit(roots []int, references func(int) []int, accept func(int) bool) map[int]bool {
+	visited := make(map[int]bool)
+	queue := append([]int(nil), roots...)
+	for len(queue) > 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		if visited[idx] {
+			continue
+		}
+		visited[idx] = true
+		for _, referenced := range references(idx) {
+			if !visited[referenced] && accept(referenced) {
+				queue = append(queue, referenced)
+			}
+		}
+	}
+	return visited
+}
+
+// compiledRule is a rule whose patterns have been compiled, nil
+// patterns match everything
+type compiledRule struct {
+	name         *regexp.Regexp
+	groupVersion *regexp.Regexp
+	category     *regexp.Regexp
+}
+
+// compiledRules is a list of rules matching a type when any of them does
+type compiledRules []compiledRule
+
+func compileRules(rules []Rule) (compiledRules, error) {
+	result := make(compiledRules, 0, len(rules))
+	for _, rule := range rules {
+		var compiled compiledRule
+		var err error
+		if compiled.name, err = compilePattern(rule.Name); err != nil {
+			return nil, err
+		}
+		if compiled.groupVersion, err = compilePattern(rule.GroupVersion); err != nil {
+			return nil, err
+		}
+		if compiled.category, err = compilePattern(rule.Category); err != nil {
+			return nil, err
+		}
+		result = append(result, compiled)
+	}
+	return result, nil
+}
+
+// compilePattern converts a glob or a regular expression to a regular
+// expression. The empty pattern matches everything, and is returned as nil.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(pattern, regexpPrefix) {
+		result, err := regexp.Compile(strings.TrimPrefix(pattern, regexpPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter pattern %q: %w", pattern, err)
+		}
+		return result, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+func (rules compiledRules) match(kubeStructure parser.KubeStructure) bool {
+	for _, rule := range rules {
+		if matchPattern(rule.name, kubeStructure.Name) &&
+			matchPattern(rule.groupVersion, kubeStructure.GroupVersion.String()) &&
+			matchPattern(rule.category, kubeStructure.Category) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern *regexp.Regexp, value string) bool {
+	return pattern == nil || pattern.MatchString(value)
+}
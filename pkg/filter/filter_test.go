@@ -0,0 +1,167 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+const testPackagePath = "example.com/api/v1"
+
+// namedType is a reference to a type of the test package
+func namedType(name string) parser.TypeInfo {
+	return parser.TypeInfo{
+		Name:     name,
+		BaseType: name,
+		Internal: true,
+		Package:  testPackagePath,
+		Kind:     parser.TypeKindNamed,
+	}
+}
+
+// structure is a type of the test package whose fields refer to the passed types
+func structure(name string, category string, resource *parser.KubeResource, fieldTypes ...parser.TypeInfo) parser.KubeStructure {
+	result := parser.KubeStructure{
+		Name:         name,
+		Package:      testPackagePath,
+		GroupVersion: parser.GroupVersion{Group: "example.com", Version: "v1"},
+		Resource:     resource,
+		Annotations:  parser.Annotations{Category: category},
+	}
+	for _, fieldType := range fieldTypes {
+		result.Fields = append(result.Fields, parser.KubeField{Name: fieldType.Name, Type: fieldType})
+	}
+	return result
+}
+
+// testTypes are two Kinds, Cluster and Pooler, with their Lists and the
+// types used by their specifications
+func testTypes() parser.KubeTypes {
+	return parser.KubeTypes{
+		structure("Cluster", "core", &parser.KubeResource{Kind: "Cluster"}, namedType("ClusterSpec")),
+		structure("ClusterList", "core", &parser.KubeResource{Kind: "ClusterList", List: true},
+			parser.TypeInfo{Name: "[]Cluster", Kind: parser.TypeKindSlice, Elem: &parser.TypeInfo{
+				Name: "Cluster", BaseType: "Cluster", Package: testPackagePath, Kind: parser.TypeKindNamed,
+			}}),
+		structure("ClusterSpec", "", nil, namedType("BackupConfiguration"), namedType("Shared")),
+		structure("BackupConfiguration", "backup", nil),
+		structure("Pooler", "internal", &parser.KubeResource{Kind: "Pooler"}, namedType("PoolerSpec")),
+		structure("PoolerSpec", "", nil, namedType("Shared"), namedType("PoolerTemplate")),
+		structure("PoolerTemplate", "", nil),
+		structure("Shared", "", nil),
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Rule
+	}{
+		{value: "Cluster*", expected: Rule{Name: "Cluster*"}},
+		{value: "name=Cluster*", expected: Rule{Name: "Cluster*"}},
+		{value: "category=core", expected: Rule{Category: "core"}},
+		{
+			value:    "name=Cluster*,groupVersion=*/v1,category=core",
+			expected: Rule{Name: "Cluster*", GroupVersion: "*/v1", Category: "core"},
+		},
+		{value: "groupversion=re:^example\\.com/v1$", expected: Rule{GroupVersion: "re:^example\\.com/v1$"}},
+		{value: "re:name=x", expected: Rule{Name: "re:name=x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if rule := ParseRule(tt.value); rule != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, rule)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{
+			name: "no rules",
+			expected: []string{
+				"Cluster", "ClusterList", "ClusterSpec", "BackupConfiguration",
+				"Pooler", "PoolerSpec", "PoolerTemplate", "Shared",
+			},
+		},
+		{
+			name:     "exclude the lists",
+			filter:   Filter{Exclude: []Rule{{Name: "*List"}}},
+			expected: []string{"Cluster", "ClusterSpec", "BackupConfiguration", "Pooler", "PoolerSpec", "PoolerTemplate", "Shared"},
+		},
+		{
+			name:     "exclude a Kind and the types only it uses",
+			filter:   Filter{Exclude: []Rule{{Category: "internal"}}},
+			expected: []string{"Cluster", "ClusterList", "ClusterSpec", "BackupConfiguration", "Shared"},
+		},
+		{
+			name:     "include a Kind and the types it uses",
+			filter:   Filter{Include: []Rule{{Name: "Pooler"}}},
+			expected: []string{"Pooler", "PoolerSpec", "PoolerTemplate", "Shared"},
+		},
+		{
+			name: "include and exclude",
+			filter: Filter{
+				Include: []Rule{{Name: "Cluster"}},
+				Exclude: []Rule{{Category: "backup"}},
+			},
+			expected: []string{"Cluster", "ClusterSpec", "Shared"},
+		},
+		{
+			name:     "regular expressions",
+			filter:   Filter{Include: []Rule{{Name: "re:^(Cluster|Pooler)$", GroupVersion: "example.com/*"}}},
+			expected: []string{"Cluster", "ClusterSpec", "BackupConfiguration", "Pooler", "PoolerSpec", "PoolerTemplate", "Shared"},
+		},
+		{
+			name:   "no match",
+			filter: Filter{Include: []Rule{{Name: "Cluster", GroupVersion: "*/v2"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kt, err := tt.filter.Apply(testTypes())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, kubeStructure := range kt {
+				names = append(names, kubeStructure.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestApplyInvalidPattern(t *testing.T) {
+	filter := Filter{Include: []Rule{{Name: "re:("}}}
+	if _, err := filter.Apply(testTypes()); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
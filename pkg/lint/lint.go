@@ -0,0 +1,196 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint contain the code checking the documentation coverage
+// of the API types
+package lint
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// Coverage is the number of documented elements out of the total
+type Coverage struct {
+	Documented int
+	Total      int
+}
+
+// Percentage returns the percentage of documented elements, which is
+// 100 when there are no elements at all
+func (c Coverage) Percentage() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return float64(c.Documented) * 100 / float64(c.Total)
+}
+
+func (c *Coverage) add(documented bool) {
+	c.Total++
+	if documented {
+		c.Documented++
+	}
+}
+
+// TypeCoverage is the documentation coverage of a type, including its
+// fields and its enum values
+type TypeCoverage struct {
+	Name         string
+	GroupVersion parser.GroupVersion
+	Coverage
+}
+
+// Report is the documentation coverage of a set of types
+type Report struct {
+	Types []TypeCoverage
+	Total Coverage
+}
+
+// Check checks the documentation of the passed types, adding a warning
+// to the diagnostics for every undocumented type, field or enum value.
+// When a minimum coverage percentage is given, an error is added if the
+// overall coverage is lower than that.
+func Check(kt parser.KubeTypes, minCoverage float64, report *diagnostics.Diagnostics) Report {
+	var result Report
+
+	// The fields promoted from inlined structures are documented in each
+	// structure inlining them, but they must be checked and counted only once
+	checked := make(map[parser.Position]bool)
+	check := func(typeCoverage *TypeCoverage, pos parser.Position, documented bool, problem func()) bool {
+		typeCoverage.add(documented)
+		if pos.IsValid() {
+			if checked[pos] {
+				return false
+			}
+			checked[pos] = true
+		}
+		result.Total.add(documented)
+		if !documented {
+			problem()
+		}
+		return true
+	}
+
+	for _, kubeStructure := range kt {
+		typeCoverage := TypeCoverage{
+			Name:         kubeStructure.Name,
+			GroupVersion: kubeStructure.GroupVersion,
+		}
+
+		// The types synthesised from anonymous structures can't have a
+		// doc comment
+		if !kubeStructure.Anonymous {
+			documented := kubeStructure.Doc != "" || kubeStructure.DeprecationMessage != ""
+			check(&typeCoverage, kubeStructure.Position, documented, func() {
+				warnUndocumented(report, kubeStructure.Position, "type", kubeStructure.Name, kubeStructure.RawDoc)
+			})
+		}
+
+		for _, field := range kubeStructure.Fields {
+			name := fmt.Sprintf("%v.%v", kubeStructure.Name, field.Name)
+			// A deprecated field may be documented only by its deprecation message
+			documented := field.Doc != "" || field.DeprecationMessage != ""
+			firstCheck := check(&typeCoverage, field.Position, documented, func() {
+				warnUndocumented(report, field.Position, "field", name, field.RawDoc)
+			})
+			if firstCheck && field.Doc != "" && !startsWithName(field.Doc, field.Name, field.GoName) {
+				warnf(report, field.Position, "doc-field-name",
+					"the documentation of field %v should start with the field name", name)
+			}
+		}
+
+		for _, value := range kubeStructure.Values {
+			check(&typeCoverage, value.Position, value.Doc != "", func() {
+				warnUndocumented(report, value.Position, "enum value", value.Name, value.RawDoc)
+			})
+		}
+
+		result.Types = append(result.Types, typeCoverage)
+	}
+
+	if minCoverage > 0 && result.Total.Percentage() < minCoverage {
+		report.Errorf("", 0, 0, "coverage",
+			"documentation coverage %.1f%% is lower than the minimum %.1f%%",
+			result.Total.Percentage(), minCoverage)
+	}
+
+	return result
+}
+
+// warnUndocumented reports an element without documentation, telling apart
+// the elements without a doc comment from the ones whose doc comment only
+// contains markers or TODOs
+func warnUndocumented(
+	report *diagnostics.Diagnostics,
+	pos parser.Position,
+	kind string,
+	name string,
+	rawDoc string,
+) {
+	if strings.TrimSpace(rawDoc) == "" {
+		warnf(report, pos, "undocumented", "%v %v is not documented", kind, name)
+		return
+	}
+	warnf(report, pos, "empty-doc",
+		"the documentation of %v %v is empty once markers and TODOs are removed", kind, name)
+}
+
+func warnf(report *diagnostics.Diagnostics, pos parser.Position, rule string, format string, args ...interface{}) {
+	report.Warnf(pos.Filename, pos.Line, pos.Column, rule, format, args...)
+}
+
+// startsWithName returns whether the documentation starts with one of the
+// passed names, i.e. the JSON and the Go name of a field, ignoring the case
+func startsWithName(doc string, names ...string) bool {
+	words := strings.FieldsFunc(doc, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if len(words) == 0 {
+		return false
+	}
+	for _, name := range names {
+		if name != "" && strings.EqualFold(words[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the coverage of each type and the overall one
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, typeCoverage := range r.Types {
+		name := typeCoverage.Name
+		if gv := typeCoverage.GroupVersion.String(); gv != "" {
+			name = gv + "/" + name
+		}
+		if _, err := fmt.Fprintf(tw, "%v\t%v/%v\t%.1f%%\n",
+			name, typeCoverage.Documented, typeCoverage.Total, typeCoverage.Percentage()); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(tw, "total\t%v/%v\t%.1f%%\n",
+		r.Total.Documented, r.Total.Total, r.Total.Percentage()); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+func TestCheck(t *testing.T) {
+	kt := parser.KubeTypes{
+		{
+			Name: "Cluster",
+			Doc:  "Cluster is a cluster",
+			Fields: []parser.KubeField{
+				{Name: "image", GoName: "ImageName", Doc: "ImageName is the image"},
+				{Name: "size", GoName: "Size", Doc: "The size"},
+				{Name: "storage", GoName: "Storage", RawDoc: "+optional"},
+				{
+					Name:        "mode",
+					GoName:      "Mode",
+					Deprecation: parser.Deprecation{Deprecated: true, DeprecationMessage: "use image"},
+				},
+			},
+		},
+		{Name: "ClusterSpec"},
+	}
+
+	var report diagnostics.Diagnostics
+	result := Check(kt, 80, &report)
+
+	var rules []string
+	for _, diagnostic := range report {
+		rules = append(rules, diagnostic.Rule)
+	}
+	expected := []string{"doc-field-name", "empty-doc", "undocumented", "coverage"}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected the rules %v, got %v", expected, rules)
+	}
+	if result.Total != (Coverage{Documented: 4, Total: 6}) {
+		t.Errorf("expected 4 documented elements out of 6, got %+v", result.Total)
+	}
+}
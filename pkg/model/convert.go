@@ -0,0 +1,375 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/doctree"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// FromKubeTypes builds the model of a list of types
+func FromKubeTypes(kt parser.KubeTypes) Model {
+	result := Model{
+		APIVersion: APIVersion,
+		Types:      make([]KubeStructure, 0, len(kt)),
+	}
+	for _, kubeStructure := range kt {
+		result.Types = append(result.Types, fromKubeStructure(kubeStructure))
+	}
+	return result
+}
+
+// KubeTypes returns the types described by the model
+func (m Model) KubeTypes() parser.KubeTypes {
+	result := make(parser.KubeTypes, 0, len(m.Types))
+	for _, kubeStructure := range m.Types {
+		result = append(result, kubeStructure.toKubeStructure())
+	}
+	return result
+}
+
+func fromKubeStructure(kubeStructure parser.KubeStructure) KubeStructure {
+	result := KubeStructure{
+		Name:         kubeStructure.Name,
+		Doc:          kubeStructure.Doc,
+		DocTree:      fromBlocks(kubeStructure.DocTree.Blocks),
+		RawDoc:       kubeStructure.RawDoc,
+		Package:      kubeStructure.Package,
+		GroupVersion: GroupVersion(kubeStructure.GroupVersion),
+		Resource:     fromKubeResource(kubeStructure.Resource),
+		Deprecation:  Deprecation(kubeStructure.Deprecation),
+		Annotations:  Annotations(kubeStructure.Annotations),
+		Validations:  fromValidations(kubeStructure.Validations),
+		Anonymous:    kubeStructure.Anonymous,
+		Position:     Position(kubeStructure.Position),
+		Hidden:       kubeStructure.Hidden,
+	}
+	for _, field := range kubeStructure.Fields {
+		result.Fields = append(result.Fields, KubeField{
+			Name:        field.Name,
+			GoName:      field.GoName,
+			Type:        fromTypeInfo(field.Type),
+			Doc:         field.Doc,
+			DocTree:     fromBlocks(field.DocTree.Blocks),
+			RawDoc:      field.RawDoc,
+			Mandatory:   field.Mandatory,
+			OmitEmpty:   field.OmitEmpty,
+			Validations: fromValidations(field.Validations),
+			Default:     field.Default,
+			Deprecation: Deprecation(field.Deprecation),
+			Annotations: Annotations(field.Annotations),
+			Position:    Position(field.Position),
+			Hidden:      field.Hidden,
+		})
+	}
+	for _, inherited := range kubeStructure.Inherits {
+		result.Inherits = append(result.Inherits, fromTypeInfo(inherited))
+	}
+	for _, inherited := range kubeStructure.HiddenInherits {
+		result.HiddenInherits = append(result.HiddenInherits, fromTypeInfo(inherited))
+	}
+	if kubeStructure.Underlying != nil {
+		underlying := fromTypeInfo(*kubeStructure.Underlying)
+		result.Underlying = &underlying
+	}
+	for _, value := range kubeStructure.Values {
+		result.Values = append(result.Values, KubeEnumValue{
+			Name:     value.Name,
+			Value:    value.Value,
+			Doc:      value.Doc,
+			DocTree:  fromBlocks(value.DocTree.Blocks),
+			RawDoc:   value.RawDoc,
+			Position: Position(value.Position),
+		})
+	}
+	return result
+}
+
+func (kubeStructure KubeStructure) toKubeStructure() parser.KubeStructure {
+	result := parser.KubeStructure{
+		Name:         kubeStructure.Name,
+		Doc:          kubeStructure.Doc,
+		DocTree:      doctree.Document{Blocks: toBlocks(kubeStructure.DocTree)},
+		RawDoc:       kubeStructure.RawDoc,
+		Package:      kubeStructure.Package,
+		GroupVersion: parser.GroupVersion(kubeStructure.GroupVersion),
+		Resource:     kubeStructure.Resource.toKubeResource(),
+		Deprecation:  parser.Deprecation(kubeStructure.Deprecation),
+		Annotations:  parser.Annotations(kubeStructure.Annotations),
+		Validations:  kubeStructure.Validations.toValidations(),
+		Anonymous:    kubeStructure.Anonymous,
+		Position:     parser.Position(kubeStructure.Position),
+		Hidden:       kubeStructure.Hidden,
+	}
+	for _, field := range kubeStructure.Fields {
+		result.Fields = append(result.Fields, parser.KubeField{
+			Name:        field.Name,
+			GoName:      field.GoName,
+			Type:        field.Type.toTypeInfo(),
+			Doc:         field.Doc,
+			DocTree:     doctree.Document{Blocks: toBlocks(field.DocTree)},
+			RawDoc:      field.RawDoc,
+			Mandatory:   field.Mandatory,
+			OmitEmpty:   field.OmitEmpty,
+			Validations: field.Validations.toValidations(),
+			Default:     field.Default,
+			Deprecation: parser.Deprecation(field.Deprecation),
+			Annotations: parser.Annotations(field.Annotations),
+			Position:    parser.Position(field.Position),
+			Hidden:      field.Hidden,
+		})
+	}
+	for _, inherited := range kubeStructure.Inherits {
+		result.Inherits = append(result.Inherits, inherited.toTypeInfo())
+	}
+	for _, inherited := range kubeStructure.HiddenInherits {
+		result.HiddenInherits = append(result.HiddenInherits, inherited.toTypeInfo())
+	}
+	if kubeStructure.Underlying != nil {
+		underlying := kubeStructure.Underlying.toTypeInfo()
+		result.Underlying = &underlying
+	}
+	for _, value := range kubeStructure.Values {
+		result.Values = append(result.Values, parser.KubeEnumValue{
+			Name:     value.Name,
+			Value:    value.Value,
+			Doc:      value.Doc,
+			DocTree:  doctree.Document{Blocks: toBlocks(value.DocTree)},
+			RawDoc:   value.RawDoc,
+			Position: parser.Position(value.Position),
+		})
+	}
+	return result
+}
+
+func fromTypeInfo(info parser.TypeInfo) TypeInfo {
+	result := TypeInfo{
+		Name:        info.Name,
+		BaseType:    info.BaseType,
+		Constructor: info.Constructor,
+		Internal:    info.Internal,
+		Package:     info.Package,
+		Kind:        string(info.Kind),
+	}
+	if info.Elem != nil {
+		elem := fromTypeInfo(*info.Elem)
+		result.Elem = &elem
+	}
+	if info.Key != nil {
+		key := fromTypeInfo(*info.Key)
+		result.Key = &key
+	}
+	for _, typeArg := range info.TypeArgs {
+		result.TypeArgs = append(result.TypeArgs, fromTypeInfo(typeArg))
+	}
+	return result
+}
+
+func (info TypeInfo) toTypeInfo() parser.TypeInfo {
+	result := parser.TypeInfo{
+		Name:        info.Name,
+		BaseType:    info.BaseType,
+		Constructor: info.Constructor,
+		Internal:    info.Internal,
+		Package:     info.Package,
+		Kind:        parser.TypeKind(info.Kind),
+	}
+	if info.Elem != nil {
+		elem := info.Elem.toTypeInfo()
+		result.Elem = &elem
+	}
+	if info.Key != nil {
+		key := info.Key.toTypeInfo()
+		result.Key = &key
+	}
+	for _, typeArg := range info.TypeArgs {
+		result.TypeArgs = append(result.TypeArgs, typeArg.toTypeInfo())
+	}
+	return result
+}
+
+func fromKubeResource(resource *parser.KubeResource) *KubeResource {
+	if resource == nil {
+		return nil
+	}
+
+	result := KubeResource{
+		Kind:       resource.Kind,
+		List:       resource.List,
+		Scope:      resource.Scope,
+		Plural:     resource.Plural,
+		Singular:   resource.Singular,
+		ShortNames: resource.ShortNames,
+		Categories: resource.Categories,
+		Subresources: Subresources{
+			Status: resource.Subresources.Status,
+		},
+	}
+	for _, column := range resource.PrintColumns {
+		result.PrintColumns = append(result.PrintColumns, PrintColumn(column))
+	}
+	if scale := resource.Subresources.Scale; scale != nil {
+		converted := ScaleSubresource(*scale)
+		result.Subresources.Scale = &converted
+	}
+	return &result
+}
+
+func (resource *KubeResource) toKubeResource() *parser.KubeResource {
+	if resource == nil {
+		return nil
+	}
+
+	result := parser.KubeResource{
+		Kind:       resource.Kind,
+		List:       resource.List,
+		Scope:      resource.Scope,
+		Plural:     resource.Plural,
+		Singular:   resource.Singular,
+		ShortNames: resource.ShortNames,
+		Categories: resource.Categories,
+		Subresources: parser.Subresources{
+			Status: resource.Subresources.Status,
+		},
+	}
+	for _, column := range resource.PrintColumns {
+		result.PrintColumns = append(result.PrintColumns, parser.PrintColumn(column))
+	}
+	if scale := resource.Subresources.Scale; scale != nil {
+		converted := parser.ScaleSubresource(*scale)
+		result.Subresources.Scale = &converted
+	}
+	return &result
+}
+
+func fromValidations(v parser.Validations) *Validations {
+	if v.IsEmpty() {
+		return nil
+	}
+
+	result := Validations{
+		Minimum:          v.Minimum,
+		Maximum:          v.Maximum,
+		ExclusiveMinimum: v.ExclusiveMinimum,
+		ExclusiveMaximum: v.ExclusiveMaximum,
+		MultipleOf:       v.MultipleOf,
+		MinLength:        v.MinLength,
+		MaxLength:        v.MaxLength,
+		Pattern:          v.Pattern,
+		Format:           v.Format,
+		Enum:             v.Enum,
+		MinItems:         v.MinItems,
+		MaxItems:         v.MaxItems,
+		UniqueItems:      v.UniqueItems,
+		MinProperties:    v.MinProperties,
+		MaxProperties:    v.MaxProperties,
+		Type:             v.Type,
+		Nullable:         v.Nullable,
+	}
+	for _, rule := range v.Rules {
+		result.Rules = append(result.Rules, ValidationRule(rule))
+	}
+	return &result
+}
+
+func (v *Validations) toValidations() parser.Validations {
+	if v == nil {
+		return parser.Validations{}
+	}
+
+	result := parser.Validations{
+		Minimum:          v.Minimum,
+		Maximum:          v.Maximum,
+		ExclusiveMinimum: v.ExclusiveMinimum,
+		ExclusiveMaximum: v.ExclusiveMaximum,
+		MultipleOf:       v.MultipleOf,
+		MinLength:        v.MinLength,
+		MaxLength:        v.MaxLength,
+		Pattern:          v.Pattern,
+		Format:           v.Format,
+		Enum:             v.Enum,
+		MinItems:         v.MinItems,
+		MaxItems:         v.MaxItems,
+		UniqueItems:      v.UniqueItems,
+		MinProperties:    v.MinProperties,
+		MaxProperties:    v.MaxProperties,
+		Type:             v.Type,
+		Nullable:         v.Nullable,
+	}
+	for _, rule := range v.Rules {
+		result.Rules = append(result.Rules, parser.ValidationRule(rule))
+	}
+	return result
+}
+
+func fromBlocks(blocks []doctree.Block) []Block {
+	var result []Block
+	for _, block := range blocks {
+		converted := Block{
+			Kind:    string(block.Kind),
+			Ordered: block.Ordered,
+			Code:    block.Code,
+		}
+		for _, span := range block.Text {
+			converted.Text = append(converted.Text, Inline{
+				Kind:       string(span.Kind),
+				Text:       span.Text,
+				URL:        span.URL,
+				ImportPath: span.ImportPath,
+				Recv:       span.Recv,
+				Name:       span.Name,
+			})
+		}
+		for _, item := range block.Items {
+			converted.Items = append(converted.Items, ListItem{
+				Number: item.Number,
+				Blocks: fromBlocks(item.Blocks),
+			})
+		}
+		result = append(result, converted)
+	}
+	return result
+}
+
+func toBlocks(blocks []Block) []doctree.Block {
+	var result []doctree.Block
+	for _, block := range blocks {
+		converted := doctree.Block{
+			Kind:    doctree.BlockKind(block.Kind),
+			Ordered: block.Ordered,
+			Code:    block.Code,
+		}
+		for _, span := range block.Text {
+			converted.Text = append(converted.Text, doctree.Inline{
+				Kind:       doctree.InlineKind(span.Kind),
+				Text:       span.Text,
+				URL:        span.URL,
+				ImportPath: span.ImportPath,
+				Recv:       span.Recv,
+				Name:       span.Name,
+			})
+		}
+		for _, item := range block.Items {
+			converted.Items = append(converted.Items, doctree.ListItem{
+				Number: item.Number,
+				Blocks: toBlocks(item.Blocks),
+			})
+		}
+		result = append(result, converted)
+	}
+	return result
+}
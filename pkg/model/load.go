@@ -0,0 +1,132 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// ErrorUnsupportedVersion means that the model has been written in a format
+// which is not supported by this version of the tool
+var ErrorUnsupportedVersion = errors.New("unsupported model version")
+
+// ErrorInvalidType means that the model contains an incomplete type,
+// i.e. a slice without the type of its elements
+var ErrorInvalidType = errors.New("invalid type")
+
+// Read decodes a model, checking that its format is supported and that
+// its types are complete
+func Read(r io.Reader) (Model, error) {
+	var result Model
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return Model{}, err
+	}
+
+	if result.APIVersion != APIVersion {
+		return Model{}, fmt.Errorf("%w %q, expected %q", ErrorUnsupportedVersion, result.APIVersion, APIVersion)
+	}
+	if err := result.validate(); err != nil {
+		return Model{}, err
+	}
+	return result, nil
+}
+
+// validate checks the types referred by the structures, their fields
+// and the types they inherit from
+func (m Model) validate() error {
+	for _, kubeStructure := range m.Types {
+		types := append(append([]TypeInfo{}, kubeStructure.Inherits...), kubeStructure.HiddenInherits...)
+		if kubeStructure.Underlying != nil {
+			types = append(types, *kubeStructure.Underlying)
+		}
+		for _, info := range types {
+			if err := info.validate(); err != nil {
+				return fmt.Errorf("type %v: %w", kubeStructure.Name, err)
+			}
+		}
+		for _, field := range kubeStructure.Fields {
+			if err := field.Type.validate(); err != nil {
+				return fmt.Errorf("field %v.%v: %w", kubeStructure.Name, field.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks that the type constructors have the types they are
+// built on, as they are followed by the renderers and by the checkers
+func (info TypeInfo) validate() error {
+	switch parser.TypeKind(info.Kind) {
+	case parser.TypeKindPointer, parser.TypeKindSlice, parser.TypeKindArray:
+		if info.Elem == nil {
+			return fmt.Errorf("%w %v: the %v has no element type", ErrorInvalidType, info.Name, info.Kind)
+		}
+	case parser.TypeKindMap:
+		if info.Key == nil || info.Elem == nil {
+			return fmt.Errorf("%w %v: the map has no key or element type", ErrorInvalidType, info.Name)
+		}
+	}
+
+	nested := append([]TypeInfo{}, info.TypeArgs...)
+	if info.Elem != nil {
+		nested = append(nested, *info.Elem)
+	}
+	if info.Key != nil {
+		nested = append(nested, *info.Key)
+	}
+	for _, nestedInfo := range nested {
+		if err := nestedInfo.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetKubeTypesFromModels return the k8s types contained in the models
+// written in the passed files, in the order in which they are given. The
+// files which cannot be read are returned as diagnostics.
+func GetKubeTypesFromModels(fileNames []string) (parser.KubeTypes, diagnostics.Diagnostics, error) {
+	var report diagnostics.Diagnostics
+	var result parser.KubeTypes
+	for _, fileName := range fileNames {
+		file, err := os.Open(fileName) // #nosec
+		if err != nil {
+			report.Errorf(fileName, 0, 0, "load", "%v", err)
+			continue
+		}
+
+		m, err := Read(file)
+		_ = file.Close()
+		switch {
+		case errors.Is(err, ErrorUnsupportedVersion), errors.Is(err, ErrorInvalidType):
+			report.Errorf(fileName, 0, 0, "model", "%v", err)
+			continue
+		case err != nil:
+			report.Errorf(fileName, 0, 0, "syntax", "%v", err)
+			continue
+		}
+		result = append(result, m.KubeTypes()...)
+	}
+	return result, report, nil
+}
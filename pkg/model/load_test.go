@@ -0,0 +1,92 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+func TestRoundTrip(t *testing.T) {
+	elem := parser.TypeInfo{Name: "Spec", BaseType: "Spec", Internal: true, Package: "example.com/api/v1",
+		Kind: parser.TypeKindNamed}
+	kt := parser.KubeTypes{
+		{
+			Name:    "Cluster",
+			Package: "example.com/api/v1",
+			Fields: []parser.KubeField{
+				{Name: "specs", GoName: "Specs", Type: parser.TypeInfo{
+					Name: "[]Spec", BaseType: "Spec", Constructor: "[]", Internal: true,
+					Package: "example.com/api/v1", Kind: parser.TypeKindSlice, Elem: &elem,
+				}},
+				{Name: "internal", GoName: "Internal", Hidden: true, Type: parser.TypeInfo{
+					Name: "string", BaseType: "string", Kind: parser.TypeKindNamed,
+				}},
+			},
+			HiddenInherits: []parser.TypeInfo{{Name: "metav1.ObjectMeta", BaseType: "ObjectMeta",
+				Package: "k8s.io/apimachinery/pkg/apis/meta/v1", Kind: parser.TypeKindNamed}},
+		},
+	}
+
+	output, err := json.Marshal(FromKubeTypes(kt))
+	if err != nil {
+		t.Fatalf("cannot write the model: %v", err)
+	}
+	m, err := Read(bytes.NewReader(output))
+	if err != nil {
+		t.Fatalf("cannot read the model: %v", err)
+	}
+	if result := m.KubeTypes(); !reflect.DeepEqual(result, kt) {
+		t.Errorf("expected %+v, got %+v", kt, result)
+	}
+}
+
+func TestReadInvalidTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		fieldType string
+	}{
+		{name: "slice without element", fieldType: `{"name": "[]Spec", "baseType": "Spec", "kind": "slice"}`},
+		{name: "pointer without element", fieldType: `{"name": "*Spec", "baseType": "Spec", "kind": "pointer"}`},
+		{
+			name:      "map without key",
+			fieldType: `{"name": "map[string]int", "baseType": "int", "kind": "map", "elem": {"name": "int", "kind": "named"}}`,
+		},
+		{
+			name: "nested slice without element",
+			fieldType: `{"name": "*[]Spec", "baseType": "Spec", "kind": "pointer",
+				"elem": {"name": "[]Spec", "baseType": "Spec", "kind": "slice"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(`{"apiVersion": "` + APIVersion + `", "types": [
+				{"name": "Cluster", "groupVersion": {}, "position": {},
+				 "fields": [{"name": "spec", "type": ` + tt.fieldType + `, "position": {}}]}]}`))
+			if !errors.Is(err, ErrorInvalidType) {
+				t.Errorf("expected an invalid type error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model contain the JSON representation of the parsed types, which
+// contains everything the renderers need. The model can be generated once and
+// rendered later, or elsewhere, without having access to the Go sources
+package model
+
+// APIVersion is the version of the model format. It must be changed
+// whenever the model changes in a way which is not backward compatible
+const APIVersion = "k8s-api-docgen/model/v1"
+
+// Model is the JSON representation of a list of parser.KubeTypes
+type Model struct {
+	// The version of the model format, which is APIVersion
+	APIVersion string `json:"apiVersion"`
+
+	// The documented types
+	Types []KubeStructure `json:"types"`
+}
+
+// KubeStructure is the representation of parser.KubeStructure
+type KubeStructure struct {
+	Name         string        `json:"name"`
+	Doc          string        `json:"doc,omitempty"`
+	DocTree      []Block       `json:"docTree,omitempty"`
+	RawDoc       string        `json:"rawDoc,omitempty"`
+	Package      string        `json:"package,omitempty"`
+	GroupVersion GroupVersion  `json:"groupVersion"`
+	Resource     *KubeResource `json:"resource,omitempty"`
+	Deprecation
+	Annotations
+	Validations    *Validations    `json:"validations,omitempty"`
+	Fields         []KubeField     `json:"fields,omitempty"`
+	Inherits       []TypeInfo      `json:"inherits,omitempty"`
+	HiddenInherits []TypeInfo      `json:"hiddenInherits,omitempty"`
+	Underlying     *TypeInfo       `json:"underlying,omitempty"`
+	Values         []KubeEnumValue `json:"values,omitempty"`
+	Anonymous      bool            `json:"anonymous,omitempty"`
+	Position       Position        `json:"position"`
+	Hidden         bool            `json:"hidden,omitempty"`
+}
+
+// KubeField is the representation of parser.KubeField
+type KubeField struct {
+	Name        string       `json:"name"`
+	GoName      string       `json:"goName,omitempty"`
+	Type        TypeInfo     `json:"type"`
+	Doc         string       `json:"doc,omitempty"`
+	DocTree     []Block      `json:"docTree,omitempty"`
+	RawDoc      string       `json:"rawDoc,omitempty"`
+	Mandatory   bool         `json:"mandatory,omitempty"`
+	OmitEmpty   bool         `json:"omitEmpty,omitempty"`
+	Validations *Validations `json:"validations,omitempty"`
+	Default     string       `json:"default,omitempty"`
+	Deprecation
+	Annotations
+	Position Position `json:"position"`
+	Hidden   bool     `json:"hidden,omitempty"`
+}
+
+// KubeEnumValue is the representation of parser.KubeEnumValue
+type KubeEnumValue struct {
+	Name     string   `json:"name"`
+	Value    string   `json:"value"`
+	Doc      string   `json:"doc,omitempty"`
+	DocTree  []Block  `json:"docTree,omitempty"`
+	RawDoc   string   `json:"rawDoc,omitempty"`
+	Position Position `json:"position"`
+}
+
+// TypeInfo is the representation of parser.TypeInfo
+type TypeInfo struct {
+	Name        string     `json:"name"`
+	BaseType    string     `json:"baseType"`
+	Constructor string     `json:"constructor,omitempty"`
+	Internal    bool       `json:"internal,omitempty"`
+	Package     string     `json:"package,omitempty"`
+	Kind        string     `json:"kind"`
+	Elem        *TypeInfo  `json:"elem,omitempty"`
+	Key         *TypeInfo  `json:"key,omitempty"`
+	TypeArgs    []TypeInfo `json:"typeArgs,omitempty"`
+}
+
+// GroupVersion is the representation of parser.GroupVersion
+type GroupVersion struct {
+	Group   string `json:"group,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Position is the representation of parser.Position
+type Position struct {
+	Filename string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// Deprecation is the representation of parser.Deprecation
+type Deprecation struct {
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+	ReplacedBy         string `json:"replacedBy,omitempty"`
+}
+
+// Annotations is the representation of parser.Annotations
+type Annotations struct {
+	DisplayName string   `json:"displayName,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Notes       []string `json:"notes,omitempty"`
+}
+
+// KubeResource is the representation of parser.KubeResource
+type KubeResource struct {
+	Kind         string        `json:"kind"`
+	List         bool          `json:"list,omitempty"`
+	Scope        string        `json:"scope,omitempty"`
+	Plural       string        `json:"plural,omitempty"`
+	Singular     string        `json:"singular,omitempty"`
+	ShortNames   []string      `json:"shortNames,omitempty"`
+	Categories   []string      `json:"categories,omitempty"`
+	PrintColumns []PrintColumn `json:"printColumns,omitempty"`
+	Subresources Subresources  `json:"subresources"`
+}
+
+// PrintColumn is the representation of parser.PrintColumn
+type PrintColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	JSONPath    string `json:"jsonPath"`
+	Description string `json:"description,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Priority    int    `json:"priority,omitempty"`
+}
+
+// Subresources is the representation of parser.Subresources
+type Subresources struct {
+	Status bool              `json:"status,omitempty"`
+	Scale  *ScaleSubresource `json:"scale,omitempty"`
+}
+
+// ScaleSubresource is the representation of parser.ScaleSubresource
+type ScaleSubresource struct {
+	SpecReplicasPath   string `json:"specReplicasPath"`
+	StatusReplicasPath string `json:"statusReplicasPath"`
+	LabelSelectorPath  string `json:"labelSelectorPath,omitempty"`
+}
+
+// Validations is the representation of parser.Validations
+type Validations struct {
+	Minimum          *float64         `json:"minimum,omitempty"`
+	Maximum          *float64         `json:"maximum,omitempty"`
+	ExclusiveMinimum bool             `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool             `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64         `json:"multipleOf,omitempty"`
+	MinLength        *int64           `json:"minLength,omitempty"`
+	MaxLength        *int64           `json:"maxLength,omitempty"`
+	Pattern          string           `json:"pattern,omitempty"`
+	Format           string           `json:"format,omitempty"`
+	Enum             []string         `json:"enum,omitempty"`
+	MinItems         *int64           `json:"minItems,omitempty"`
+	MaxItems         *int64           `json:"maxItems,omitempty"`
+	UniqueItems      bool             `json:"uniqueItems,omitempty"`
+	MinProperties    *int64           `json:"minProperties,omitempty"`
+	MaxProperties    *int64           `json:"maxProperties,omitempty"`
+	Type             string           `json:"type,omitempty"`
+	Nullable         bool             `json:"nullable,omitempty"`
+	Rules            []ValidationRule `json:"rules,omitempty"`
+}
+
+// ValidationRule is the representation of parser.ValidationRule
+type ValidationRule struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message,omitempty"`
+}
+
+// Block is the representation of doctree.Block
+type Block struct {
+	Kind    string     `json:"kind"`
+	Text    []Inline   `json:"text,omitempty"`
+	Items   []ListItem `json:"items,omitempty"`
+	Ordered bool       `json:"ordered,omitempty"`
+	Code    string     `json:"code,omitempty"`
+}
+
+// ListItem is the representation of doctree.ListItem
+type ListItem struct {
+	Number string  `json:"number,omitempty"`
+	Blocks []Block `json:"blocks,omitempty"`
+}
+
+// Inline is the representation of doctree.Inline
+type Inline struct {
+	Kind       string `json:"kind"`
+	Text       string `json:"text,omitempty"`
+	URL        string `json:"url,omitempty"`
+	ImportPath string `json:"importPath,omitempty"`
+	Recv       string `json:"recv,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+// The markers understood only by this tool, which are ignored by controller-gen
+const (
+	// docgenHideMarker excludes from the documentation a type, a field
+	// or, when used before the package clause, every type declared in a file
+	docgenHideMarker = "docgen:hide"
+
+	// docgenDisplayNameMarker sets the name shown in the documentation
+	docgenDisplayNameMarker = "docgen:displayName"
+
+	// docgenCategoryMarker sets the category a type belongs to
+	docgenCategoryMarker = "docgen:category"
+
+	// docgenNoteMarker adds a note to the documentation, and can be repeated
+	docgenNoteMarker = "docgen:note"
+)
+
+// Annotations is the documentation of a type or a field declared via
+// the `+docgen:` markers
+type Annotations struct {
+	// The name to be shown instead of the Go or JSON one, if any
+	DisplayName string
+
+	// The category of the element, if any
+	Category string
+
+	// The notes to be shown after the documentation
+	Notes []string
+}
+
+// getAnnotations reads the annotations declared in the passed markers
+func getAnnotations(m markers) Annotations {
+	var annotations Annotations
+	if value, ok := m.lookup(docgenDisplayNameMarker); ok {
+		annotations.DisplayName = unquoteMarkerValue(value)
+	}
+	if value, ok := m.lookup(docgenCategoryMarker); ok {
+		annotations.Category = unquoteMarkerValue(value)
+	}
+	for _, value := range m.lookupAll(docgenNoteMarker) {
+		if note := unquoteMarkerValue(value); note != "" {
+			annotations.Notes = append(annotations.Notes, note)
+		}
+	}
+	return annotations
+}
+
+// isHidden returns whether the passed markers exclude an element from
+// the documentation
+func isHidden(m markers) bool {
+	return m.has(docgenHideMarker)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"strings"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/doctree"
+)
+
+// docSeparator is the line separating the documentation from the notes
+// for the developers, which are not documented
+const docSeparator = "---"
+
+// cutAtSeparator removes everything following a line containing only
+// the `---` separator. A `---` in the middle of a line is kept
+func cutAtSeparator(rawDoc string) string {
+	lines := strings.Split(rawDoc, "\n")
+	for idx, line := range lines {
+		if strings.TrimSpace(line) == docSeparator {
+			return strings.Join(lines[:idx], "\n") + "\n"
+		}
+	}
+	return rawDoc
+}
+
+// parseDoc parses a doc comment into a document tree, once the developers
+// notes, the markers and the one line TODOs have been removed
+func parseDoc(docParser doctree.Parser, rawDoc string) doctree.Document {
+	var lines []string
+	for _, line := range strings.Split(cutAtSeparator(rawDoc), "\n") {
+		leading := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(leading, "TODO") || strings.HasPrefix(leading, "+") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return docParser.Parse(strings.Join(lines, "\n"))
+}
+
+// docParser returns the parser of the doc comments written in the file,
+// which resolves the doc links using the imports of the file
+func (scope typeScope) docParser() doctree.Parser {
+	return doctree.Parser{
+		PackagePath: scope.packagePath,
+		LookupPackage: func(name string) (string, bool) {
+			importPath, ok := scope.imports[name]
+			return importPath, ok
+		},
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// predeclaredTypes are the names of the types which can be
+// used in a conversion without being declared in the package
+var predeclaredTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+// noIota is passed as the value of `iota` when evaluating an expression
+// which is not part of a constant declaration, where `iota` is not defined
+const noIota = -1
+
+// constantValue returns the value of a constant declared with the given
+// expression, where `iota` has the passed value. Strings are unquoted,
+// and the expressions which cannot be evaluated are returned as written
+// in the source code
+func (p *packageParser) constantValue(value ast.Expr, iotaValue int) string {
+	if value == nil {
+		return ""
+	}
+
+	result, ok := p.evalConstant(value, iotaValue, map[string]bool{})
+	if !ok {
+		return types.ExprString(value)
+	}
+	switch result.Kind() {
+	case constant.String:
+		return constant.StringVal(result)
+	case constant.Float:
+		return result.String()
+	default:
+		return result.ExactString()
+	}
+}
+
+// evalConstant evaluates a constant expression made of literals, of `iota`,
+// of the operators and of the constants declared in the package, i.e. `1 <<
+// iota` or `"postgresql." + Domain`. The conversions, i.e. `Phase("ready")`,
+// are evaluated as their operand, and `len` as the length of a constant
+// string. The constants being evaluated are tracked in visiting to stop on
+// invalid recursive declarations.
+func (p *packageParser) evalConstant(expr ast.Expr, iotaValue int, visiting map[string]bool) (constant.Value, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		result := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		return result, result.Kind() != constant.Unknown
+
+	case *ast.ParenExpr:
+		return p.evalConstant(e.X, iotaValue, visiting)
+
+	case *ast.CallExpr:
+		fun, ok := e.Fun.(*ast.Ident)
+		if !ok || len(e.Args) != 1 {
+			return nil, false
+		}
+		switch {
+		case p.isDeclaredType(fun.Name) || predeclaredTypes[fun.Name]:
+			return p.evalConstant(e.Args[0], iotaValue, visiting)
+		case fun.Name == "len":
+			x, ok := p.evalConstant(e.Args[0], iotaValue, visiting)
+			if !ok || x.Kind() != constant.String {
+				return nil, false
+			}
+			return constant.MakeInt64(int64(len(constant.StringVal(x)))), true
+		}
+		return nil, false
+
+	case *ast.UnaryExpr:
+		x, ok := p.evalConstant(e.X, iotaValue, visiting)
+		if !ok || !validUnaryOp(e.Op, x.Kind()) {
+			return nil, false
+		}
+		return constant.UnaryOp(e.Op, x, 0), true
+
+	case *ast.BinaryExpr:
+		x, ok := p.evalConstant(e.X, iotaValue, visiting)
+		if !ok {
+			return nil, false
+		}
+		y, ok := p.evalConstant(e.Y, iotaValue, visiting)
+		if !ok {
+			return nil, false
+		}
+		return evalBinaryOp(x, e.Op, y)
+
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			if iotaValue == noIota {
+				return nil, false
+			}
+			return constant.MakeInt64(int64(iotaValue)), true
+		case "true", "false":
+			return constant.MakeBool(e.Name == "true"), true
+		}
+
+		// The constants declared with `iota` cannot be evaluated out
+		// of their declaration, where its value is not known
+		value := p.constantExpr(e.Name)
+		if value == nil || visiting[e.Name] {
+			return nil, false
+		}
+		visiting[e.Name] = true
+		defer delete(visiting, e.Name)
+		return p.evalConstant(value, noIota, visiting)
+	}
+	return nil, false
+}
+
+// validUnaryOp returns whether a unary operator can be applied
+// to a constant of the passed kind
+func validUnaryOp(op token.Token, kind constant.Kind) bool {
+	switch op {
+	case token.ADD, token.SUB:
+		return kind == constant.Int || kind == constant.Float
+	case token.XOR:
+		return kind == constant.Int
+	case token.NOT:
+		return kind == constant.Bool
+	}
+	return false
+}
+
+// evalBinaryOp applies a binary operator to two constants, checking
+// that the operation is valid, as go/constant panics otherwise
+func evalBinaryOp(x constant.Value, op token.Token, y constant.Value) (constant.Value, bool) {
+	isNumber := func(v constant.Value) bool {
+		return v.Kind() == constant.Int || v.Kind() == constant.Float
+	}
+	isInt := func(v constant.Value) bool {
+		return v.Kind() == constant.Int
+	}
+
+	switch op {
+	case token.SHL, token.SHR:
+		shift, ok := constant.Uint64Val(y)
+		if !isInt(x) || !isInt(y) || !ok {
+			return nil, false
+		}
+		return constant.Shift(x, op, uint(shift)), true
+
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if x.Kind() != y.Kind() && !(isNumber(x) && isNumber(y)) {
+			return nil, false
+		}
+		if x.Kind() == constant.Bool && op != token.EQL && op != token.NEQ {
+			return nil, false
+		}
+		return constant.MakeBool(constant.Compare(x, op, y)), true
+
+	case token.ADD:
+		if x.Kind() == constant.String && y.Kind() == constant.String {
+			return constant.BinaryOp(x, op, y), true
+		}
+		if !isNumber(x) || !isNumber(y) {
+			return nil, false
+		}
+
+	case token.SUB, token.MUL:
+		if !isNumber(x) || !isNumber(y) {
+			return nil, false
+		}
+
+	case token.QUO:
+		if !isNumber(x) || !isNumber(y) || constant.Sign(y) == 0 {
+			return nil, false
+		}
+		if isInt(x) && isInt(y) {
+			// Force the integer division
+			op = token.QUO_ASSIGN
+		}
+
+	case token.REM:
+		if !isInt(x) || !isInt(y) || constant.Sign(y) == 0 {
+			return nil, false
+		}
+
+	case token.AND, token.OR, token.XOR, token.AND_NOT:
+		if !isInt(x) || !isInt(y) {
+			return nil, false
+		}
+
+	case token.LAND, token.LOR:
+		if x.Kind() != constant.Bool || y.Kind() != constant.Bool {
+			return nil, false
+		}
+
+	default:
+		return nil, false
+	}
+	return constant.BinaryOp(x, op, y), true
+}
+
+// constantExpr returns the expression assigned to a constant declared
+// at the package level, or nil if there is no such constant or if it
+// implicitly repeats the previous expression
+func (p *packageParser) constantExpr(name string) ast.Expr {
+	for _, genDecl := range p.constDecls {
+		for _, valueSpec := range p.constSpecs[genDecl] {
+			for idx, ident := range valueSpec.Names {
+				if ident.Name == name && idx < len(valueSpec.Values) {
+					return valueSpec.Values[idx]
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnumValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		consts   string
+		expected []string
+	}{
+		{
+			name:     "strings",
+			consts:   `const (A Level = "a"; B Level = "b")`,
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "iota",
+			consts:   `const (A Level = iota; B; C)`,
+			expected: []string{"0", "1", "2"},
+		},
+		{
+			name:     "iota plus one",
+			consts:   `const (A Level = iota + 1; B; C)`,
+			expected: []string{"1", "2", "3"},
+		},
+		{
+			name:     "shifted iota",
+			consts:   `const (A Level = 1 << iota; B; C)`,
+			expected: []string{"1", "2", "4"},
+		},
+		{
+			name:     "skipped values",
+			consts:   `const (_ Level = iota * 10; A; B)`,
+			expected: []string{"10", "20"},
+		},
+		{
+			name:     "constants of the package",
+			consts:   `const prefix = "x-"; const (A Level = prefix + "a"; B Level = Level(prefix + "b"))`,
+			expected: []string{"x-a", "x-b"},
+		},
+		{
+			name:     "integer division and negative numbers",
+			consts:   `const (A Level = 7 / 2; B Level = -(1 + 2); C Level = 1.5 * 2)`,
+			expected: []string{"3", "-3", "3"},
+		},
+		{
+			name:     "conversions and builtin functions",
+			consts:   `const (A Level = Level(string("a")); B Level = len("abc"); C Level = Weird("d"))`,
+			expected: []string{"a", "3", `Weird("d")`},
+		},
+		{
+			name:     "expressions which cannot be evaluated",
+			consts:   `const (A Level = 1 / 0; B Level = unsafe.Sizeof(x))`,
+			expected: []string{"1 / 0", "unsafe.Sizeof(x)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kt, _ := parseSource(t, "package v1\n// Level is a level\ntype Level string\n"+tt.consts)
+
+			var values []string
+			for _, value := range findType(t, kt, "Level").Values {
+				values = append(values, value.Value)
+			}
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("expected values %v, got %v", tt.expected, values)
+			}
+		})
+	}
+}
+
+func TestGetGroupVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  []string
+		expected GroupVersion
+	}{
+		{
+			name:     "package name",
+			sources:  []string{"package v1"},
+			expected: GroupVersion{Version: "v1"},
+		},
+		{
+			name: "string literals",
+			sources: []string{`package v1
+var GroupVersion = schema.GroupVersion{Group: "apps.example.com", Version: "v1beta1"}`},
+			expected: GroupVersion{Group: "apps.example.com", Version: "v1beta1"},
+		},
+		{
+			name: "constants",
+			sources: []string{`package v1
+const GroupName = "apps." + Domain
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: Version}`, `package v1
+const (
+	Domain  = "example.com"
+	Version = "v2"
+)`},
+			expected: GroupVersion{Group: "apps.example.com", Version: "v2"},
+		},
+		{
+			name: "markers",
+			sources: []string{`// +groupName=markers.example.com
+// +versionName=v3
+package v1
+var GroupVersion = schema.GroupVersion{Group: "apps.example.com", Version: "v1"}`},
+			expected: GroupVersion{Group: "markers.example.com", Version: "v3"},
+		},
+		{
+			name: "recursive constants",
+			sources: []string{`package v1
+const (A = B; B = A)
+var GroupVersion = schema.GroupVersion{Group: A, Version: "v1"}`},
+			expected: GroupVersion{Version: "v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kt, _ := parseSource(t, append(tt.sources, "package v1\n// Foo is a foo\ntype Foo struct{}")...)
+			if gv := findType(t, kt, "Foo").GroupVersion; gv != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, gv)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+)
+
+// crdKind is the Kind of the manifests we read
+const crdKind = "CustomResourceDefinition"
+
+// crdManifest is the subset of a CustomResourceDefinition we use. Both
+// apiextensions.k8s.io/v1 and the older v1beta1, where the schema can be
+// shared by all the versions, are supported
+type crdManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Spec       struct {
+		Group string `yaml:"group"`
+		Names struct {
+			Kind       string   `yaml:"kind"`
+			ListKind   string   `yaml:"listKind"`
+			Plural     string   `yaml:"plural"`
+			Singular   string   `yaml:"singular"`
+			ShortNames []string `yaml:"shortNames"`
+			Categories []string `yaml:"categories"`
+		} `yaml:"names"`
+		Scope      string       `yaml:"scope"`
+		Version    string       `yaml:"version"`
+		Versions   []crdVersion `yaml:"versions"`
+		Validation *struct {
+			OpenAPIV3Schema *openAPISchema `yaml:"openAPIV3Schema"`
+		} `yaml:"validation"`
+		Subresources             *crdSubresources   `yaml:"subresources"`
+		AdditionalPrinterColumns []crdPrinterColumn `yaml:"additionalPrinterColumns"`
+	} `yaml:"spec"`
+}
+
+// crdVersion is a version served by a CustomResourceDefinition
+type crdVersion struct {
+	Name               string `yaml:"name"`
+	Deprecated         bool   `yaml:"deprecated"`
+	DeprecationWarning string `yaml:"deprecationWarning"`
+	Schema             *struct {
+		OpenAPIV3Schema *openAPISchema `yaml:"openAPIV3Schema"`
+	} `yaml:"schema"`
+	Subresources             *crdSubresources   `yaml:"subresources"`
+	AdditionalPrinterColumns []crdPrinterColumn `yaml:"additionalPrinterColumns"`
+}
+
+// crdSubresources are the subresources of a CustomResourceDefinition
+type crdSubresources struct {
+	Status *struct{} `yaml:"status"`
+	Scale  *struct {
+		SpecReplicasPath   string `yaml:"specReplicasPath"`
+		StatusReplicasPath string `yaml:"statusReplicasPath"`
+		LabelSelectorPath  string `yaml:"labelSelectorPath"`
+	} `yaml:"scale"`
+}
+
+// crdPrinterColumn is an additional printer column of a CustomResourceDefinition.
+// The JSONPath key is `jsonPath` in v1 and `JSONPath` in v1beta1
+type crdPrinterColumn struct {
+	Name         string `yaml:"name"`
+	Type         string `yaml:"type"`
+	JSONPath     string `yaml:"jsonPath"`
+	JSONPathBeta string `yaml:"JSONPath"`
+	Description  string `yaml:"description"`
+	Format       string `yaml:"format"`
+	Priority     int    `yaml:"priority"`
+}
+
+// GetKubeTypesFromCRDs return the k8s types described by the OpenAPI
+// schemas of CustomResourceDefinition manifests. The arguments can be
+// YAML files, which may contain several documents, or directories which
+// are searched recursively for YAML files. Each schema becomes a type named
+// after the Kind, while each nested object becomes a structure named after
+// the path leading to it, i.e. `ClusterSpecStorage`. The problems found in
+// the manifests are returned as diagnostics.
+func GetKubeTypesFromCRDs(args []string) (KubeTypes, diagnostics.Diagnostics, error) {
+	var report diagnostics.Diagnostics
+
+	var fileNames []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			report.Errorf(relativePath(arg), 0, 0, "load", "%v", err)
+			continue
+		}
+		if !info.IsDir() {
+			fileNames = append(fileNames, arg)
+			continue
+		}
+
+		err = filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && (strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+				fileNames = append(fileNames, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, report, err
+		}
+	}
+
+	converter := newSchemaConverter(&report)
+	for _, fileName := range fileNames {
+		for _, manifest := range readCRDManifests(fileName, &report) {
+			converter.convertCRD(manifest, relativePath(fileName))
+		}
+	}
+
+	resolveReplacements(converter.types)
+	report.Sort()
+	return converter.types, report, nil
+}
+
+// readCRDManifests reads the CustomResourceDefinitions contained in a
+// YAML file, skipping the other documents
+func readCRDManifests(fileName string, report *diagnostics.Diagnostics) []crdManifest {
+	content, err := os.ReadFile(fileName) // #nosec
+	if err != nil {
+		report.Errorf(relativePath(fileName), 0, 0, "load", "%v", err)
+		return nil
+	}
+
+	var result []crdManifest
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for idx := 1; ; idx++ {
+		var manifest crdManifest
+		err := decoder.Decode(&manifest)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			report.Errorf(relativePath(fileName), 0, 0, "syntax", "document %v: %v", idx, err)
+			break
+		}
+
+		if manifest.Kind != crdKind {
+			if manifest.Kind != "" {
+				report.Warnf(relativePath(fileName), 0, 0, "crd",
+					"skipping document %v, which is a %v and not a %v", idx, manifest.Kind, crdKind)
+			}
+			continue
+		}
+		result = append(result, manifest)
+	}
+	return result
+}
+
+// convertCRD adds the types described by the schemas of each version of a
+// CustomResourceDefinition
+func (c *schemaConverter) convertCRD(manifest crdManifest, fileName string) {
+	spec := manifest.Spec
+
+	versions := spec.Versions
+	if len(versions) == 0 && spec.Version != "" {
+		versions = []crdVersion{{Name: spec.Version}}
+	}
+
+	for _, version := range versions {
+		var openAPIV3Schema *openAPISchema
+		if version.Schema != nil {
+			openAPIV3Schema = version.Schema.OpenAPIV3Schema
+		} else if spec.Validation != nil {
+			openAPIV3Schema = spec.Validation.OpenAPIV3Schema
+		}
+		if openAPIV3Schema == nil {
+			c.report.Warnf(fileName, 0, 0, "crd", "%v %v/%v has no OpenAPI v3 schema",
+				spec.Names.Kind, spec.Group, version.Name)
+			continue
+		}
+
+		subresources := version.Subresources
+		if subresources == nil {
+			subresources = spec.Subresources
+		}
+		printColumns := version.AdditionalPrinterColumns
+		if len(printColumns) == 0 {
+			printColumns = spec.AdditionalPrinterColumns
+		}
+
+		resource := &KubeResource{
+			Kind:         spec.Names.Kind,
+			Scope:        spec.Scope,
+			Plural:       spec.Names.Plural,
+			Singular:     spec.Names.Singular,
+			ShortNames:   spec.Names.ShortNames,
+			Categories:   spec.Names.Categories,
+			PrintColumns: convertPrintColumns(printColumns),
+			Subresources: convertSubresources(subresources),
+		}
+		if resource.Singular == "" {
+			resource.Singular = strings.ToLower(resource.Kind)
+		}
+
+		var deprecation Deprecation
+		if version.Deprecated {
+			deprecation = Deprecation{
+				Deprecated:         true,
+				DeprecationMessage: version.DeprecationWarning,
+			}
+			deprecation.ReplacedBy, _ = replacedBy(version.DeprecationWarning)
+		}
+
+		c.convertRoot(
+			GroupVersion{Group: spec.Group, Version: version.Name},
+			spec.Names.ListKind, openAPIV3Schema, resource, deprecation, fileName)
+	}
+}
+
+func convertPrintColumns(columns []crdPrinterColumn) []PrintColumn {
+	var result []PrintColumn
+	for _, column := range columns {
+		jsonPath := column.JSONPath
+		if jsonPath == "" {
+			jsonPath = column.JSONPathBeta
+		}
+		result = append(result, PrintColumn{
+			Name:        column.Name,
+			Type:        column.Type,
+			JSONPath:    jsonPath,
+			Description: column.Description,
+			Format:      column.Format,
+			Priority:    column.Priority,
+		})
+	}
+	return result
+}
+
+func convertSubresources(subresources *crdSubresources) Subresources {
+	var result Subresources
+	if subresources == nil {
+		return result
+	}
+
+	result.Status = subresources.Status != nil
+	if scale := subresources.Scale; scale != nil {
+		result.Scale = &ScaleSubresource{
+			SpecReplicasPath:   scale.SpecReplicasPath,
+			StatusReplicasPath: scale.StatusReplicasPath,
+			LabelSelectorPath:  scale.LabelSelectorPath,
+		}
+	}
+	return result
+}
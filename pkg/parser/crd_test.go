@@ -0,0 +1,115 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testCRD = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusters.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names:
+    kind: Cluster
+    listKind: ClusterList
+    plural: clusters
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        description: Cluster is a cluster
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [storage]
+            properties:
+              instances:
+                type: integer
+                default: 1
+              storage:
+                type: object
+                properties:
+                  size:
+                    type: string
+  - name: v1beta1
+    deprecated: true
+    deprecationWarning: use example.com/v1 instead
+    schema:
+      openAPIV3Schema:
+        type: object
+`
+
+func TestGetKubeTypesFromCRDs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "clusters.yaml"), []byte(testCRD), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kt, report, err := GetKubeTypesFromCRDs([]string{dir})
+	if err != nil {
+		t.Fatalf("cannot read the CRDs: %v", err)
+	}
+	if len(report) != 1 || report[0].Rule != "crd" {
+		t.Errorf("expected the ConfigMap to be skipped with a warning, got %v", report)
+	}
+
+	var names []string
+	for _, kubeStructure := range kt {
+		names = append(names, kubeStructure.GroupVersion.String()+"/"+kubeStructure.Name)
+	}
+	expected := []string{
+		"example.com/v1/Cluster", "example.com/v1/ClusterSpec", "example.com/v1/ClusterSpecStorage",
+		"example.com/v1/ClusterList", "example.com/v1beta1/Cluster", "example.com/v1beta1/ClusterList",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected the types %v, got %v", expected, names)
+	}
+
+	if resource := kt[0].Resource; resource.Kind != "Cluster" || resource.Plural != "clusters" ||
+		resource.Singular != "cluster" || resource.Scope != ScopeNamespaced {
+		t.Errorf("unexpected resource of Cluster %+v", resource)
+	}
+	if resource := kt[3].Resource; !reflect.DeepEqual(resource, &KubeResource{Kind: "ClusterList", List: true}) {
+		t.Errorf("unexpected resource of ClusterList %+v", resource)
+	}
+
+	instances, storage := kt[1].Fields[0], kt[1].Fields[1]
+	if instances.Mandatory || instances.Default != "1" {
+		t.Errorf("expected instances to be optional with default 1, got %+v", instances)
+	}
+	if !storage.Mandatory || storage.Type.Name != "ClusterSpecStorage" || !kt[2].Anonymous {
+		t.Errorf("expected storage to be a mandatory ClusterSpecStorage, got %+v", storage)
+	}
+
+	if deprecation := kt[4].Deprecation; !deprecation.Deprecated ||
+		deprecation.DeprecationMessage != "use example.com/v1 instead" {
+		t.Errorf("expected example.com/v1beta1 to be deprecated, got %+v", deprecation)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// getDefault returns the JSON representation of the default value declared
+// via the `+kubebuilder:default` or `+default` markers, or an empty string
+// if there is none
+func getDefault(m markers) string {
+	for _, name := range []string{"kubebuilder:default", "default"} {
+		if value, ok := m.lookup(name); ok {
+			return parseDefaultValue(value)
+		}
+	}
+	return ""
+}
+
+// parseDefaultValue converts the value of a default marker to JSON. The
+// value can be a JSON document, a list in the `{a,b,c}` form used by
+// controller-gen, or a scalar. Unquoted scalars which are not valid JSON,
+// like `+kubebuilder:default=Always`, are considered strings.
+func parseDefaultValue(value string) string {
+	value = strings.TrimSpace(value)
+
+	if json.Valid([]byte(value)) {
+		var buffer bytes.Buffer
+		if err := json.Compact(&buffer, []byte(value)); err == nil {
+			return buffer.String()
+		}
+	}
+
+	if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
+		var items []json.RawMessage
+		for _, item := range splitMarkerValue(value[1:len(value)-1], ',') {
+			items = append(items, json.RawMessage(parseDefaultValue(item)))
+		}
+		if items == nil {
+			items = []json.RawMessage{}
+		}
+		result, err := json.Marshal(items)
+		if err == nil {
+			return string(result)
+		}
+	}
+
+	result, _ := json.Marshal(unquoteMarkerValue(value))
+	return string(result)
+}
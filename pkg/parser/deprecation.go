@@ -0,0 +1,147 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Deprecation describes whether a type or a field is deprecated
+type Deprecation struct {
+	// True if the element is deprecated
+	Deprecated bool
+
+	// The explanation given in the documentation or in the markers
+	DeprecationMessage string
+
+	// The element which should be used instead, if known
+	ReplacedBy string
+}
+
+const deprecatedPrefix = "Deprecated:"
+
+// replacedByRegexp matches the usual ways to mention the replacement of a
+// deprecated element, i.e. "use `storage` instead" or "replaced by Storage".
+// The bare words are only candidates, as they may be prose, i.e. "use
+// something else"
+var replacedByRegexp = regexp.MustCompile(
+	"(?i)\\b(?:use|replaced by|in favou?r of)\\s+(?:`([^`]+)`|([A-Za-z_][A-Za-z0-9_.]*))")
+
+// extractDeprecation looks for the Go-style "Deprecated:" paragraph in the
+// raw documentation of an element. It returns the documentation without
+// that paragraph and the deprecation information.
+func extractDeprecation(rawDoc string) (string, Deprecation) {
+	var deprecation Deprecation
+	var paragraphs []string
+	for _, paragraph := range strings.Split(rawDoc, "\n\n") {
+		trimmed := strings.TrimSpace(paragraph)
+		if !deprecation.Deprecated && strings.HasPrefix(trimmed, deprecatedPrefix) {
+			deprecation.Deprecated = true
+			deprecation.DeprecationMessage = strings.Join(
+				strings.Fields(strings.TrimPrefix(trimmed, deprecatedPrefix)), " ")
+			continue
+		}
+		paragraphs = append(paragraphs, paragraph)
+	}
+
+	deprecation.ReplacedBy, _ = replacedBy(deprecation.DeprecationMessage)
+	if !deprecation.Deprecated {
+		return rawDoc, deprecation
+	}
+	return strings.TrimRight(strings.Join(paragraphs, "\n\n"), "\n") + "\n", deprecation
+}
+
+// markerDeprecation returns the deprecation declared via markers. The
+// `+kubebuilder:deprecatedversion` marker deprecates the API version of
+// a Kind, with an optional warning, while `+deprecated` can be used on
+// any element with an optional message
+func markerDeprecation(m markers) Deprecation {
+	var deprecation Deprecation
+	if value, ok := m.lookup("kubebuilder:deprecatedversion"); ok {
+		deprecation.Deprecated = true
+		deprecation.DeprecationMessage = parseMarkerArgs(value)["warning"]
+	} else if value, ok := m.lookup("deprecated"); ok {
+		deprecation.Deprecated = true
+		deprecation.DeprecationMessage = unquoteMarkerValue(value)
+	}
+
+	deprecation.ReplacedBy, _ = replacedBy(deprecation.DeprecationMessage)
+	return deprecation
+}
+
+// getDeprecation extracts the deprecation of an element from its raw
+// documentation and its markers, returning the documentation without
+// the "Deprecated:" paragraph
+func getDeprecation(rawDoc string, m markers) (string, Deprecation) {
+	doc, deprecation := extractDeprecation(rawDoc)
+	if fromMarkers := markerDeprecation(m); fromMarkers.Deprecated {
+		deprecation.Deprecated = true
+		if deprecation.DeprecationMessage == "" {
+			deprecation.DeprecationMessage = fromMarkers.DeprecationMessage
+			deprecation.ReplacedBy = fromMarkers.ReplacedBy
+		}
+	}
+	return doc, deprecation
+}
+
+// replacedBy finds the replacement mentioned in a deprecation message,
+// returning whether it is a bare word, which needs to be resolved via
+// resolveReplacements
+func replacedBy(message string) (string, bool) {
+	match := replacedByRegexp.FindStringSubmatch(message)
+	if match == nil {
+		return "", false
+	}
+	if match[1] != "" {
+		return match[1], false
+	}
+	return strings.TrimRight(match[2], "."), true
+}
+
+// resolveReplacements drops the replacements of the deprecated elements which
+// are bare words not naming a type or a field, i.e. `Storage`, `storage` or
+// `Cluster.storage`, as they are part of the prose of the message. Both the
+// JSON and the Go names of the fields are accepted
+func resolveReplacements(kt KubeTypes) {
+	names := make(map[string]bool)
+	for _, kubeStructure := range kt {
+		names[kubeStructure.Name] = true
+		names[kubeStructure.QualifiedName()] = true
+		for _, field := range kubeStructure.Fields {
+			for _, name := range []string{field.Name, field.GoName} {
+				if name != "" {
+					names[name] = true
+					names[kubeStructure.Name+"."+name] = true
+				}
+			}
+		}
+	}
+
+	resolve := func(deprecation *Deprecation) {
+		name, bare := replacedBy(deprecation.DeprecationMessage)
+		if bare && name == deprecation.ReplacedBy && !names[name] {
+			deprecation.ReplacedBy = ""
+		}
+	}
+	for i := range kt {
+		resolve(&kt[i].Deprecation)
+		for j := range kt[i].Fields {
+			resolve(&kt[i].Fields[j].Deprecation)
+		}
+	}
+}
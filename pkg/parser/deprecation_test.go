@@ -0,0 +1,150 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"testing"
+)
+
+func TestExtractDeprecation(t *testing.T) {
+	tests := []struct {
+		name        string
+		rawDoc      string
+		doc         string
+		deprecation Deprecation
+	}{
+		{
+			name:   "not deprecated",
+			rawDoc: "Storage is the storage\n",
+			doc:    "Storage is the storage\n",
+		},
+		{
+			name:   "deprecated paragraph",
+			rawDoc: "Size is the size\n\nDeprecated: use `storage`\ninstead.\n",
+			doc:    "Size is the size\n",
+			deprecation: Deprecation{
+				Deprecated:         true,
+				DeprecationMessage: "use `storage` instead.",
+				ReplacedBy:         "storage",
+			},
+		},
+		{
+			name:   "bare word",
+			rawDoc: "Size is the size\n\nDeprecated: replaced by Storage.\n",
+			doc:    "Size is the size\n",
+			deprecation: Deprecation{
+				Deprecated:         true,
+				DeprecationMessage: "replaced by Storage.",
+				ReplacedBy:         "Storage",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, deprecation := extractDeprecation(tt.rawDoc)
+			if doc != tt.doc {
+				t.Errorf("expected documentation %q, got %q", tt.doc, doc)
+			}
+			if deprecation != tt.deprecation {
+				t.Errorf("expected deprecation %+v, got %+v", tt.deprecation, deprecation)
+			}
+		})
+	}
+}
+
+func TestReplacedBy(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{message: "use something else", expected: ""},
+		{message: "use it with care", expected: ""},
+		{message: "use `something` instead", expected: "something"},
+		{message: "use `.spec.storage.size` instead", expected: ".spec.storage.size"},
+		{message: "use storage instead", expected: "storage"},
+		{message: "use StorageConfiguration instead", expected: "StorageConfiguration"},
+		{message: "replaced by Cluster.storage.", expected: "Cluster.storage"},
+		{message: "in favor of storage", expected: "storage"},
+		{message: "in favour of Storage", expected: "Storage"},
+		{message: "will be removed", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			kt, _ := parseSource(t, `package v1
+// StorageConfiguration is the storage configuration
+type StorageConfiguration struct{}
+
+// Cluster is a cluster
+type Cluster struct {
+	// Size is the size
+	//
+	// Deprecated: `+tt.message+`
+	Size string `+"`json:\"size\"`"+`
+
+	// Storage is the storage
+	Storage StorageConfiguration `+"`json:\"storage\"`"+`
+}`)
+			deprecation := findType(t, kt, "Cluster").Fields[0].Deprecation
+			if !deprecation.Deprecated {
+				t.Fatalf("the field should be deprecated")
+			}
+			if deprecation.ReplacedBy != tt.expected {
+				t.Errorf("expected replacement %q, got %q", tt.expected, deprecation.ReplacedBy)
+			}
+		})
+	}
+}
+
+func TestMarkerDeprecation(t *testing.T) {
+	tests := []struct {
+		name     string
+		markers  markers
+		expected Deprecation
+	}{
+		{
+			name:    "no markers",
+			markers: markers{"optional"},
+		},
+		{
+			name:    "deprecated version",
+			markers: markers{`kubebuilder:deprecatedversion:warning="v1 will be removed"`},
+			expected: Deprecation{
+				Deprecated:         true,
+				DeprecationMessage: "v1 will be removed",
+			},
+		},
+		{
+			name:    "deprecated with a message",
+			markers: markers{`deprecated="no longer used"`},
+			expected: Deprecation{
+				Deprecated:         true,
+				DeprecationMessage: "no longer used",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deprecation := markerDeprecation(tt.markers)
+			if deprecation != tt.expected {
+				t.Errorf("expected deprecation %+v, got %+v", tt.expected, deprecation)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"sort"
+)
+
+// GroupVersion identifies the API group and version of a type
+type GroupVersion struct {
+	// The API group (i.e. `postgresql.k8s.enterprisedb.io`), empty
+	// for the core group
+	Group string
+
+	// The API version (i.e. `v1`)
+	Version string
+}
+
+// String returns the group and version in the form used by the
+// `apiVersion` field (i.e. `postgresql.k8s.enterprisedb.io/v1`)
+func (gv GroupVersion) String() string {
+	if gv.Group == "" {
+		return gv.Version
+	}
+	return gv.Group + "/" + gv.Version
+}
+
+// getGroupVersion detects the group and version of the package. The
+// `+groupName` and `+versionName` package markers are used when present,
+// otherwise we look for the `schema.GroupVersion` literal declared in
+// `groupversion_info.go` by kubebuilder, whose values can be string
+// literals or constants declared in the package, i.e. `Group: GroupName`.
+// The version defaults to the package name.
+func (p *packageParser) getGroupVersion() GroupVersion {
+	var gv GroupVersion
+	for _, fileName := range sortedFileNames(p.pkg.Files) {
+		ast.Inspect(p.pkg.Files[fileName], func(node ast.Node) bool {
+			compositeLit, ok := node.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			selector, ok := compositeLit.Type.(*ast.SelectorExpr)
+			if !ok || selector.Sel.Name != "GroupVersion" {
+				return true
+			}
+
+			for _, elt := range compositeLit.Elts {
+				keyValue, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				key, ok := keyValue.Key.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				value, ok := p.evalConstant(keyValue.Value, noIota, map[string]bool{})
+				if !ok || value.Kind() != constant.String {
+					continue
+				}
+
+				switch key.Name {
+				case "Group":
+					gv.Group = constant.StringVal(value)
+				case "Version":
+					gv.Version = constant.StringVal(value)
+				}
+			}
+			return false
+		})
+	}
+
+	if group, ok := p.packageMarkers.lookup("groupName"); ok {
+		gv.Group = unquoteMarkerValue(group)
+	}
+	if version, ok := p.packageMarkers.lookup("versionName"); ok {
+		gv.Version = unquoteMarkerValue(version)
+	}
+	if gv.Version == "" {
+		gv.Version = p.pkg.Name
+	}
+
+	return gv
+}
+
+// sortedFileNames returns the names of the passed files in
+// lexicographic order, to process them deterministically
+func sortedFileNames(files map[string]*ast.File) []string {
+	result := make([]string, 0, len(files))
+	for fileName := range files {
+		result = append(result, fileName)
+	}
+	sort.Strings(result)
+	return result
+}
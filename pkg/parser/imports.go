@@ -0,0 +1,63 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"go/ast"
+	"strconv"
+)
+
+// typeScope contains what is needed to resolve the type names used
+// in a source file
+type typeScope struct {
+	// The import path of the package being parsed
+	packagePath string
+
+	// The import paths of the imported packages, indexed by the
+	// name used in the file to refer to them
+	imports map[string]string
+}
+
+// newTypeScope creates the scope for the passed file. The names of the
+// packages imported without an explicit name are looked up in importNames,
+// which is indexed by import path
+func newTypeScope(packagePath string, f *ast.File, importNames map[string]string) typeScope {
+	scope := typeScope{
+		packagePath: packagePath,
+		imports:     make(map[string]string),
+	}
+
+	for _, importSpec := range f.Imports {
+		importPath, err := strconv.Unquote(importSpec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		var name string
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		} else {
+			name = importNames[importPath]
+		}
+		if name == "" || name == "_" || name == "." {
+			continue
+		}
+		scope.imports[name] = importPath
+	}
+
+	return scope
+}
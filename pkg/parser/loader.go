@@ -0,0 +1,393 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+)
+
+// sourcePackage is a Go package whose files have been parsed
+type sourcePackage struct {
+	// The package import path, empty when the package has been
+	// loaded from a list of files
+	ImportPath string
+
+	// The parsed files, indexed by path
+	Files map[string]*ast.File
+
+	// The names of the packages imported without an explicit name,
+	// indexed by import path
+	ImportNames map[string]string
+}
+
+// goListPackage is the subset of the `go list -json` output we use
+type goListPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
+	Error      *struct {
+		Pos string
+		Err string
+	}
+}
+
+// generatedFileRegexp matches the comment marking generated files, as
+// described in https://golang.org/s/generatedcode
+var generatedFileRegexp = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// loadPackages parses the packages matching the given arguments. Arguments
+// ending with ".go" are considered as file names and are grouped in a single
+// package, while the other ones are package patterns, like `./api/...`,
+// which are resolved by the go command honouring the build tags.
+// Test files and generated files are always skipped. The problems found
+// in a package or in a file are reported as diagnostics, skipping it, while
+// an error is returned only when the go command cannot be run.
+func loadPackages(
+	fSet *token.FileSet,
+	args []string,
+	buildTags []string,
+	report *diagnostics.Diagnostics,
+) ([]sourcePackage, error) {
+	var fileNames []string
+	var patterns []string
+	for _, arg := range args {
+		if strings.HasSuffix(arg, ".go") {
+			fileNames = append(fileNames, arg)
+		} else {
+			patterns = append(patterns, arg)
+		}
+	}
+
+	var result []sourcePackage
+	if len(fileNames) > 0 {
+		pkg := parseFiles(fSet, "", fileNames, report)
+		if err := resolveImportNames(fSet, filepath.Dir(fileNames[0]), []sourcePackage{pkg}, buildTags, report); err != nil {
+			return nil, err
+		}
+		result = append(result, pkg)
+	}
+
+	for _, group := range groupPatterns(patterns) {
+		listedPackages, err := goList(group.dir, group.patterns, buildTags)
+		if err != nil {
+			return nil, err
+		}
+
+		var groupPackages []sourcePackage
+		for _, listedPackage := range listedPackages {
+			if listedPackage.Error != nil {
+				filename, line, column := splitPosition(listedPackage.Error.Pos)
+				report.Errorf(relativePath(filename), line, column, "load",
+					"cannot load package %v: %v", listedPackage.ImportPath, listedPackage.Error.Err)
+				continue
+			}
+
+			var packageFiles []string
+			for _, name := range append(listedPackage.GoFiles, listedPackage.CgoFiles...) {
+				packageFiles = append(packageFiles, filepath.Join(listedPackage.Dir, name))
+			}
+			groupPackages = append(groupPackages,
+				parseFiles(fSet, listedPackage.ImportPath, packageFiles, report))
+		}
+
+		if err := resolveImportNames(fSet, group.dir, groupPackages, buildTags, report); err != nil {
+			return nil, err
+		}
+		result = append(result, groupPackages...)
+	}
+
+	return result, nil
+}
+
+// resolveImportNames finds, using the go command run in the passed directory,
+// the names of the packages imported without an explicit name, which cannot be
+// deduced from the import path, i.e. `gopkg.in/yaml.v3` is named `yaml` and
+// `github.com/go-logr/logr/v2` is named `logr`. The packages which cannot be
+// loaded are reported, as the types they declare cannot be resolved.
+func resolveImportNames(
+	fSet *token.FileSet,
+	dir string,
+	packages []sourcePackage,
+	buildTags []string,
+	report *diagnostics.Diagnostics,
+) error {
+	var importPaths []string
+	seen := make(map[string]bool)
+	for _, pkg := range packages {
+		for _, f := range pkg.Files {
+			for _, importSpec := range f.Imports {
+				importPath, err := strconv.Unquote(importSpec.Path.Value)
+				if err != nil || importSpec.Name != nil || seen[importPath] {
+					continue
+				}
+				seen[importPath] = true
+				importPaths = append(importPaths, importPath)
+			}
+		}
+	}
+	if len(importPaths) == 0 {
+		return nil
+	}
+	sort.Strings(importPaths)
+
+	listedPackages, err := goList(dir, importPaths, buildTags)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string)
+	problems := make(map[string]string)
+	for _, listedPackage := range listedPackages {
+		switch {
+		case listedPackage.Error != nil:
+			problems[listedPackage.ImportPath] = listedPackage.Error.Err
+		case listedPackage.Name != "":
+			names[listedPackage.ImportPath] = listedPackage.Name
+		}
+	}
+
+	for idx := range packages {
+		packages[idx].ImportNames = names
+		for _, f := range packages[idx].Files {
+			for _, importSpec := range f.Imports {
+				importPath, err := strconv.Unquote(importSpec.Path.Value)
+				if err != nil || importSpec.Name != nil || names[importPath] != "" {
+					continue
+				}
+				pos := fSet.Position(importSpec.Pos())
+				problem := problems[importPath]
+				if problem == "" {
+					problem = "package not found"
+				}
+				report.Warnf(relativePath(pos.Filename), pos.Line, pos.Column, "load",
+					"cannot load package %v, imported without a name: %v", importPath, firstLine(problem))
+			}
+		}
+	}
+	return nil
+}
+
+// firstLine returns the first line of a message
+func firstLine(message string) string {
+	if idx := strings.Index(message, "\n"); idx >= 0 {
+		return message[:idx]
+	}
+	return message
+}
+
+// patternGroup is a set of package patterns which are resolved
+// running the go command in the same directory
+type patternGroup struct {
+	// The directory where the go command is run, empty for
+	// the current one
+	dir string
+
+	// The patterns, relative to the directory
+	patterns []string
+}
+
+// groupPatterns groups the passed package patterns by the directory in which
+// they should be resolved. The go command resolves the patterns in the context
+// of the module containing the directory where it is run, so the patterns which
+// are directories, like `../operator/api/v1` or `../operator/api/...`, are
+// resolved from that directory, in order to load packages belonging to other
+// modules too. The import paths are resolved from the current directory.
+func groupPatterns(patterns []string) []patternGroup {
+	var result []patternGroup
+	groupIndex := make(map[string]int)
+	for _, pattern := range patterns {
+		dir, relativePattern := "", pattern
+		if isDirectoryPattern(pattern) {
+			dir, relativePattern = splitDirectoryPattern(pattern)
+		}
+
+		index, ok := groupIndex[dir]
+		if !ok {
+			index = len(result)
+			groupIndex[dir] = index
+			result = append(result, patternGroup{dir: dir})
+		}
+		result[index].patterns = append(result[index].patterns, relativePattern)
+	}
+	return result
+}
+
+// isDirectoryPattern returns whether a package pattern is a directory, i.e.
+// `./api/...` or `/src/operator/api/v1`, instead of an import path
+func isDirectoryPattern(pattern string) bool {
+	return pattern == "." || pattern == ".." ||
+		strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../") ||
+		filepath.IsAbs(pattern)
+}
+
+// splitDirectoryPattern splits a directory pattern in the longest directory
+// not containing wildcards and the pattern relative to it, i.e.
+// `../operator/api/...` is split in `../operator/api` and `./...`
+func splitDirectoryPattern(pattern string) (string, string) {
+	elements := strings.Split(filepath.ToSlash(pattern), "/")
+	wildcard := len(elements)
+	for i, element := range elements {
+		if strings.Contains(element, "...") {
+			wildcard = i
+			break
+		}
+	}
+
+	dir := strings.Join(elements[:wildcard], "/")
+	switch {
+	case dir == "" && wildcard > 0:
+		dir = "/"
+	case dir == "":
+		dir = "."
+	}
+	relativePattern := "."
+	if wildcard < len(elements) {
+		relativePattern = "./" + strings.Join(elements[wildcard:], "/")
+	}
+	return filepath.FromSlash(dir), relativePattern
+}
+
+// parseFiles parses the passed files, skipping the test and the generated ones
+// and the ones containing syntax errors
+func parseFiles(
+	fSet *token.FileSet,
+	importPath string,
+	fileNames []string,
+	report *diagnostics.Diagnostics,
+) sourcePackage {
+	pkg := sourcePackage{
+		ImportPath: importPath,
+		Files:      make(map[string]*ast.File),
+	}
+
+	for _, fileName := range fileNames {
+		if strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fSet, fileName, nil, parser.ParseComments|parser.DeclarationErrors)
+		if err != nil {
+			reportError(report, relativePath(fileName), "syntax", err)
+			continue
+		}
+		if isGenerated(f) {
+			continue
+		}
+		pkg.Files[fileName] = f
+	}
+
+	return pkg
+}
+
+// reportError adds an error to the diagnostics, splitting the lists
+// of errors returned by the Go parser
+func reportError(report *diagnostics.Diagnostics, fileName string, rule string, err error) {
+	var errorList scanner.ErrorList
+	if !errors.As(err, &errorList) {
+		report.Errorf(fileName, 0, 0, rule, "%v", err)
+		return
+	}
+
+	for _, e := range errorList {
+		report.Errorf(relativePath(e.Pos.Filename), e.Pos.Line, e.Pos.Column, rule, "%v", e.Msg)
+	}
+}
+
+// splitPosition splits a position in the `file:line:column` format,
+// where the line and the column are optional
+func splitPosition(position string) (string, int, int) {
+	parts := strings.Split(position, ":")
+	var numbers []int
+	for len(parts) > 1 && len(numbers) < 2 {
+		number, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		numbers = append([]int{number}, numbers...)
+		parts = parts[:len(parts)-1]
+	}
+	numbers = append(numbers, 0, 0)
+	return strings.Join(parts, ":"), numbers[0], numbers[1]
+}
+
+// isGenerated returns whether a file has been generated by a tool
+func isGenerated(f *ast.File) bool {
+	for _, commentGroup := range f.Comments {
+		if commentGroup.Pos() > f.Package {
+			break
+		}
+		for _, comment := range commentGroup.List {
+			if generatedFileRegexp.MatchString(comment.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goList resolves the passed package patterns using the go command, which
+// is run in the passed directory or, if empty, in the current one
+func goList(dir string, patterns []string, buildTags []string) ([]goListPackage, error) {
+	args := []string{"list", "-e", "-find", "-json"}
+	if len(buildTags) > 0 {
+		args = append(args, "-tags", strings.Join(buildTags, ","))
+	}
+	args = append(args, "--")
+	args = append(args, patterns...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", args...) // #nosec
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %v", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var result []goListPackage
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var pkg goListPackage
+		err := decoder.Decode(&pkg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pkg)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGroupPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []patternGroup
+	}{
+		{
+			name:     "current directory",
+			patterns: []string{"."},
+			expected: []patternGroup{{dir: ".", patterns: []string{"."}}},
+		},
+		{
+			name:     "package in another module",
+			patterns: []string{"../operator/api/v1"},
+			expected: []patternGroup{{dir: filepath.FromSlash("../operator/api/v1"), patterns: []string{"."}}},
+		},
+		{
+			name:     "wildcards",
+			patterns: []string{"./api/...", "../operator/api/...", "./..."},
+			expected: []patternGroup{
+				{dir: filepath.FromSlash("./api"), patterns: []string{"./..."}},
+				{dir: filepath.FromSlash("../operator/api"), patterns: []string{"./..."}},
+				{dir: ".", patterns: []string{"./..."}},
+			},
+		},
+		{
+			name:     "wildcards inside an element",
+			patterns: []string{"./api/v1...", "/src/operator/api/v1"},
+			expected: []patternGroup{
+				{dir: filepath.FromSlash("./api"), patterns: []string{"./v1..."}},
+				{dir: filepath.FromSlash("/src/operator/api/v1"), patterns: []string{"."}},
+			},
+		},
+		{
+			name:     "import paths",
+			patterns: []string{"example.com/operator/api/v1", "./api/v1", "example.com/operator/api/v2"},
+			expected: []patternGroup{
+				{patterns: []string{"example.com/operator/api/v1", "example.com/operator/api/v2"}},
+				{dir: filepath.FromSlash("./api/v1"), patterns: []string{"."}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if groups := groupPatterns(tt.patterns); !reflect.DeepEqual(groups, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, groups)
+			}
+		})
+	}
+}
+
+func TestSplitPosition(t *testing.T) {
+	tests := []struct {
+		position string
+		file     string
+		line     int
+		column   int
+	}{
+		{position: "api/v1/types.go:12:3", file: "api/v1/types.go", line: 12, column: 3},
+		{position: "api/v1/types.go:12", file: "api/v1/types.go", line: 12},
+		{position: "api/v1/types.go", file: "api/v1/types.go"},
+		{position: `C:\api\types.go:1:2`, file: `C:\api\types.go`, line: 1, column: 2},
+		{position: "", file: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			file, line, column := splitPosition(tt.position)
+			if file != tt.file || line != tt.line || column != tt.column {
+				t.Errorf("expected %v:%v:%v, got %v:%v:%v", tt.file, tt.line, tt.column, file, line, column)
+			}
+		})
+	}
+}
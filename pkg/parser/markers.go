@@ -0,0 +1,199 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// markers is the list of the "+" directives found in a set of comments,
+// without the leading "+". I.e. `// +kubebuilder:validation:Minimum=1`
+// is stored as `kubebuilder:validation:Minimum=1`
+type markers []string
+
+// extractMarkers collects the markers contained in the passed comment groups
+func extractMarkers(commentGroups ...*ast.CommentGroup) markers {
+	var result markers
+	for _, commentGroup := range commentGroups {
+		if commentGroup == nil {
+			continue
+		}
+		for _, line := range strings.Split(commentGroup.Text(), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "+") {
+				result = append(result, line[1:])
+			}
+		}
+	}
+	return result
+}
+
+// typeMarkers returns the markers of a type declaration. As controller-gen
+// does, we look both in the type documentation and in the comment group
+// preceding it, when the two are separated by a single empty line
+func typeMarkers(fSet *token.FileSet, f *ast.File, spec *ast.TypeSpec, declDoc *ast.CommentGroup) markers {
+	typeDoc := spec.Doc
+	if typeDoc == nil {
+		typeDoc = declDoc
+	}
+
+	start := spec.Pos()
+	if typeDoc != nil {
+		start = typeDoc.Pos()
+	}
+	startLine := fSet.Position(start).Line
+
+	var detached *ast.CommentGroup
+	if f != nil {
+		for _, commentGroup := range f.Comments {
+			if fSet.Position(commentGroup.End()).Line == startLine-2 {
+				detached = commentGroup
+				break
+			}
+		}
+	}
+
+	return extractMarkers(detached, typeDoc)
+}
+
+// fileMarkers returns the markers declared before the package clause of
+// a file, which apply to the whole package
+func fileMarkers(f *ast.File) markers {
+	var result markers
+	for _, commentGroup := range f.Comments {
+		if commentGroup.End() < f.Package {
+			result = append(result, extractMarkers(commentGroup)...)
+		}
+	}
+	return result
+}
+
+// lookup returns the value of the first marker with the given name.
+// The value is what follows the name and one of the "=", ":=" or ":"
+// separators; markers without a value, such as `+optional`, have an
+// empty one.
+func (m markers) lookup(name string) (string, bool) {
+	values := m.lookupAll(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// lookupAll returns the values of every marker with the given name
+func (m markers) lookupAll(name string) []string {
+	var values []string
+	for _, marker := range m {
+		if !strings.HasPrefix(marker, name) {
+			continue
+		}
+
+		rest := marker[len(name):]
+		switch {
+		case rest == "":
+			values = append(values, "")
+		case strings.HasPrefix(rest, ":="):
+			values = append(values, rest[2:])
+		case strings.HasPrefix(rest, "="), strings.HasPrefix(rest, ":"):
+			values = append(values, rest[1:])
+		}
+	}
+	return values
+}
+
+// has returns whether a marker with the given name is present
+func (m markers) has(name string) bool {
+	_, ok := m.lookup(name)
+	return ok
+}
+
+// splitMarkerValue splits a marker value with the given separator, ignoring
+// the separators which are inside quotes, braces or brackets
+func splitMarkerValue(value string, separator rune) []string {
+	var result []string
+	var current strings.Builder
+	var quote rune
+	depth := 0
+
+	for _, c := range value {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		case c == separator && depth == 0:
+			result = append(result, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(c)
+	}
+
+	if current.Len() > 0 || len(result) > 0 {
+		result = append(result, current.String())
+	}
+	return result
+}
+
+// parseMarkerArgs parses the arguments of a marker in the form
+// `name="Age",type=date,JSONPath=".metadata.creationTimestamp"`.
+// The argument names are case-insensitive and are returned in lower case,
+// while the values are unquoted.
+func parseMarkerArgs(value string) map[string]string {
+	args := make(map[string]string)
+	for _, arg := range splitMarkerValue(value, ',') {
+		kv := strings.SplitN(arg, "=", 2)
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		if key == "" {
+			continue
+		}
+		if len(kv) == 1 {
+			// Arguments without a value are boolean flags
+			args[key] = "true"
+			continue
+		}
+		args[key] = unquoteMarkerValue(kv[1])
+	}
+	return args
+}
+
+// unquoteMarkerValue removes the quotes surrounding a marker value, if any
+func unquoteMarkerValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 {
+		switch value[0] {
+		case '"':
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted
+			}
+		case '`', '\'':
+			if value[len(value)-1] == value[0] {
+				return value[1 : len(value)-1]
+			}
+		}
+	}
+	return value
+}
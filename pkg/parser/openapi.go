@@ -0,0 +1,480 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/doctree"
+)
+
+const (
+	// metaV1Package is the package of the standard object metadata
+	metaV1Package = "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	// intstrPackage is the package of the IntOrString type
+	intstrPackage = "k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// openAPISchema is the subset of the OpenAPI v3 schema of a
+// CustomResourceDefinition we use
+type openAPISchema struct {
+	Type                 string              `yaml:"type"`
+	Format               string              `yaml:"format"`
+	Description          string              `yaml:"description"`
+	Properties           schemaProperties    `yaml:"properties"`
+	Items                *openAPISchema      `yaml:"items"`
+	AdditionalProperties *schemaOrBool       `yaml:"additionalProperties"`
+	Required             []string            `yaml:"required"`
+	Enum                 []interface{}       `yaml:"enum"`
+	Default              interface{}         `yaml:"default"`
+	Minimum              *float64            `yaml:"minimum"`
+	Maximum              *float64            `yaml:"maximum"`
+	ExclusiveMinimum     bool                `yaml:"exclusiveMinimum"`
+	ExclusiveMaximum     bool                `yaml:"exclusiveMaximum"`
+	MultipleOf           *float64            `yaml:"multipleOf"`
+	MinLength            *int64              `yaml:"minLength"`
+	MaxLength            *int64              `yaml:"maxLength"`
+	Pattern              string              `yaml:"pattern"`
+	MinItems             *int64              `yaml:"minItems"`
+	MaxItems             *int64              `yaml:"maxItems"`
+	UniqueItems          bool                `yaml:"uniqueItems"`
+	MinProperties        *int64              `yaml:"minProperties"`
+	MaxProperties        *int64              `yaml:"maxProperties"`
+	Nullable             bool                `yaml:"nullable"`
+	XValidations         []openAPIValidation `yaml:"x-kubernetes-validations"`
+	IntOrString          bool                `yaml:"x-kubernetes-int-or-string"`
+	EmbeddedResource     bool                `yaml:"x-kubernetes-embedded-resource"`
+}
+
+// openAPIValidation is a CEL validation rule
+type openAPIValidation struct {
+	Rule    string `yaml:"rule"`
+	Message string `yaml:"message"`
+}
+
+// schemaProperty is a property of an object schema
+type schemaProperty struct {
+	Name   string
+	Schema *openAPISchema
+}
+
+// schemaProperties are the properties of an object schema, in the
+// order in which they are written in the manifest
+type schemaProperties []schemaProperty
+
+// UnmarshalYAML implements yaml.Unmarshaler, keeping the properties order
+func (properties *schemaProperties) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var keys yaml.MapSlice
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+	var values map[string]*openAPISchema
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+
+	for _, item := range keys {
+		name := fmt.Sprint(item.Key)
+		schema := values[name]
+		if schema == nil {
+			schema = &openAPISchema{}
+		}
+		*properties = append(*properties, schemaProperty{Name: name, Schema: schema})
+	}
+	return nil
+}
+
+// schemaOrBool is the value of `additionalProperties`, which can be
+// a schema or a boolean
+type schemaOrBool struct {
+	Allows bool
+	Schema *openAPISchema
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (value *schemaOrBool) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&value.Allows); err == nil {
+		return nil
+	}
+
+	value.Allows = true
+	return unmarshal(&value.Schema)
+}
+
+// schemaConverter builds the types described by the schemas of the
+// CustomResourceDefinitions
+type schemaConverter struct {
+	types KubeTypes
+
+	// The names already used in each API version
+	names map[GroupVersion]map[string]bool
+
+	// Where to report the problems found in the schemas
+	report *diagnostics.Diagnostics
+}
+
+func newSchemaConverter(report *diagnostics.Diagnostics) *schemaConverter {
+	return &schemaConverter{
+		names:  make(map[GroupVersion]map[string]bool),
+		report: report,
+	}
+}
+
+// convertRoot adds the type of a Kind, its List and the structures
+// describing its nested objects
+func (c *schemaConverter) convertRoot(
+	groupVersion GroupVersion,
+	listKind string,
+	schema *openAPISchema,
+	resource *KubeResource,
+	deprecation Deprecation,
+	fileName string,
+) {
+	kind := resource.Kind
+	if c.isDefined(groupVersion, kind) {
+		c.report.Warnf(fileName, 0, 0, "crd", "%v %v is defined more than once, ignoring it",
+			groupVersion.String(), kind)
+		return
+	}
+
+	doc, docTree, docDeprecation := schemaDoc(schema, groupVersion)
+	if !deprecation.Deprecated {
+		deprecation = docDeprecation
+	}
+	kubeStructure := KubeStructure{
+		Name:         c.uniqueName(groupVersion, kind),
+		Doc:          doc,
+		DocTree:      docTree,
+		RawDoc:       schema.Description,
+		Package:      groupVersion.String(),
+		GroupVersion: groupVersion,
+		Resource:     resource,
+		Deprecation:  deprecation,
+		Position:     Position{Filename: fileName},
+		Inherits:     []TypeInfo{metaV1Type("TypeMeta")},
+	}
+
+	// The `apiVersion` and `kind` fields come from the inherited TypeMeta
+	// and the metadata is always the standard one, whatever the schema says
+	c.types = append(c.types, KubeStructure{})
+	idx := len(c.types) - 1
+	for _, property := range schema.Properties {
+		switch property.Name {
+		case "apiVersion", "kind":
+			continue
+		case "metadata":
+			property.Schema = &openAPISchema{Description: property.Schema.Description}
+			field := c.field(groupVersion, kubeStructure.Name, schema, property, fileName)
+			field.Type = metaV1Type("ObjectMeta")
+			kubeStructure.Fields = append(kubeStructure.Fields, field)
+		default:
+			kubeStructure.Fields = append(kubeStructure.Fields,
+				c.field(groupVersion, kubeStructure.Name, schema, property, fileName))
+		}
+	}
+	c.types[idx] = kubeStructure
+
+	if listKind == "" {
+		return
+	}
+	list := KubeStructure{
+		Name:         c.uniqueName(groupVersion, listKind),
+		Doc:          fmt.Sprintf("%v contains a list of %v", listKind, kind),
+		Package:      groupVersion.String(),
+		GroupVersion: groupVersion,
+		Resource: &KubeResource{
+			Kind: listKind,
+			List: true,
+		},
+		Deprecation: deprecation,
+		Position:    Position{Filename: fileName},
+		Inherits:    []TypeInfo{metaV1Type("TypeMeta")},
+		Fields: []KubeField{
+			{
+				Name:      "metadata",
+				Type:      metaV1Type("ListMeta"),
+				OmitEmpty: true,
+				Position:  Position{Filename: fileName},
+			},
+			{
+				Name:      "items",
+				Type:      wrapType(c.namedType(groupVersion, kubeStructure.Name), TypeKindSlice, "[]"),
+				Mandatory: true,
+				Position:  Position{Filename: fileName},
+			},
+		},
+	}
+	c.types = append(c.types, list)
+}
+
+// field converts a property of an object schema to a field, adding the
+// structures describing the nested objects
+func (c *schemaConverter) field(
+	groupVersion GroupVersion,
+	parentName string,
+	parent *openAPISchema,
+	property schemaProperty,
+	fileName string,
+) KubeField {
+	schema := property.Schema
+	mandatory := false
+	for _, name := range parent.Required {
+		if name == property.Name {
+			mandatory = true
+		}
+	}
+
+	doc, docTree, deprecation := schemaDoc(schema, groupVersion)
+	return KubeField{
+		Name:    property.Name,
+		Type:    c.schemaType(groupVersion, parentName+capitalize(property.Name), schema, fileName),
+		Doc:     doc,
+		DocTree: docTree,
+		RawDoc:  schema.Description,
+		// The schema doesn't tell how the optional fields are serialized,
+		// but they are expected to be omitted when empty
+		Mandatory:   mandatory,
+		OmitEmpty:   !mandatory,
+		Validations: schemaValidations(schema),
+		Default:     c.schemaDefault(schema, fileName),
+		Deprecation: deprecation,
+		Position:    Position{Filename: fileName},
+	}
+}
+
+// schemaType builds the type tree described by a schema. The objects with
+// properties become structures whose name is derived from nameHint.
+func (c *schemaConverter) schemaType(
+	groupVersion GroupVersion,
+	nameHint string,
+	schema *openAPISchema,
+	fileName string,
+) TypeInfo {
+	switch {
+	case schema.IntOrString:
+		return TypeInfo{
+			Name:     "intstr.IntOrString",
+			BaseType: "intstr.IntOrString",
+			Package:  intstrPackage,
+			Kind:     TypeKindNamed,
+		}
+
+	case len(schema.Properties) > 0:
+		name := c.uniqueName(groupVersion, nameHint)
+		kubeStructure := KubeStructure{
+			Name:         name,
+			Package:      groupVersion.String(),
+			GroupVersion: groupVersion,
+			Position:     Position{Filename: fileName},
+			Anonymous:    true,
+		}
+
+		// The structure is added before converting its fields, so that the
+		// nested structures follow the ones containing them
+		c.types = append(c.types, KubeStructure{})
+		idx := len(c.types) - 1
+		for _, property := range schema.Properties {
+			kubeStructure.Fields = append(kubeStructure.Fields,
+				c.field(groupVersion, name, schema, property, fileName))
+		}
+		c.types[idx] = kubeStructure
+		return c.namedType(groupVersion, name)
+
+	case schema.Type == "array":
+		elem := &openAPISchema{}
+		if schema.Items != nil {
+			elem = schema.Items
+		}
+		return wrapType(c.schemaType(groupVersion, nameHint, elem, fileName), TypeKindSlice, "[]")
+
+	case schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil:
+		key := builtinType("string")
+		info := wrapType(
+			c.schemaType(groupVersion, nameHint, schema.AdditionalProperties.Schema, fileName),
+			TypeKindMap, "map[string]")
+		info.Key = &key
+		return info
+
+	case schema.Type == "":
+		// Without a type any JSON value is allowed
+		return builtinType("any")
+
+	default:
+		return builtinType(schema.Type)
+	}
+}
+
+// schemaDoc returns the normalized and the parsed description of a schema,
+// and the deprecation declared in its "Deprecated:" paragraph. The doc links
+// refer to the types of the passed API version
+func schemaDoc(schema *openAPISchema, groupVersion GroupVersion) (string, doctree.Document, Deprecation) {
+	doc, deprecation := extractDeprecation(schema.Description)
+	if !strings.HasSuffix(doc, "\n") {
+		// Like the doc comments, the last line must be terminated
+		doc += "\n"
+	}
+	return fmtRawDoc(doc), parseDoc(doctree.Parser{PackagePath: groupVersion.String()}, doc), deprecation
+}
+
+// schemaDefault returns the JSON representation of the default value of
+// a schema, or an empty string if there is none
+func (c *schemaConverter) schemaDefault(schema *openAPISchema, fileName string) string {
+	if schema.Default == nil {
+		return ""
+	}
+
+	result, err := json.Marshal(jsonCompatible(schema.Default))
+	if err != nil {
+		c.report.Warnf(fileName, 0, 0, "crd", "invalid default value %v: %v", schema.Default, err)
+		return ""
+	}
+	return string(result)
+}
+
+// isDefined returns whether a Kind has already been converted
+func (c *schemaConverter) isDefined(groupVersion GroupVersion, kind string) bool {
+	for _, kubeStructure := range c.types {
+		if kubeStructure.Resource != nil && kubeStructure.GroupVersion == groupVersion &&
+			kubeStructure.Resource.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns a name not yet used in the API version, adding
+// a numeric suffix to the passed one if needed
+func (c *schemaConverter) uniqueName(groupVersion GroupVersion, name string) string {
+	names, ok := c.names[groupVersion]
+	if !ok {
+		names = make(map[string]bool)
+		c.names[groupVersion] = names
+	}
+
+	result := name
+	for idx := 2; names[result]; idx++ {
+		result = fmt.Sprintf("%v%v", name, idx)
+	}
+	names[result] = true
+	return result
+}
+
+// namedType refers to a structure built from a schema. The package of
+// these structures is their API version, so that the references can be
+// linked to them
+func (c *schemaConverter) namedType(groupVersion GroupVersion, name string) TypeInfo {
+	return TypeInfo{
+		Name:     name,
+		BaseType: name,
+		Internal: true,
+		Package:  groupVersion.String(),
+		Kind:     TypeKindNamed,
+	}
+}
+
+// builtinType refers to an OpenAPI type such as `string` or `integer`
+func builtinType(name string) TypeInfo {
+	return TypeInfo{
+		Name:     name,
+		BaseType: name,
+		Internal: true,
+		Kind:     TypeKindNamed,
+	}
+}
+
+// metaV1Type refers to a type of the k8s.io/apimachinery/pkg/apis/meta/v1 package
+func metaV1Type(name string) TypeInfo {
+	return TypeInfo{
+		Name:     "metav1." + name,
+		BaseType: "metav1." + name,
+		Package:  metaV1Package,
+		Kind:     TypeKindNamed,
+	}
+}
+
+// schemaValidations returns the constraints declared in a schema
+func schemaValidations(schema *openAPISchema) Validations {
+	v := Validations{
+		Minimum:          schema.Minimum,
+		Maximum:          schema.Maximum,
+		ExclusiveMinimum: schema.ExclusiveMinimum,
+		ExclusiveMaximum: schema.ExclusiveMaximum,
+		MultipleOf:       schema.MultipleOf,
+		MinLength:        schema.MinLength,
+		MaxLength:        schema.MaxLength,
+		Pattern:          schema.Pattern,
+		Format:           schema.Format,
+		MinItems:         schema.MinItems,
+		MaxItems:         schema.MaxItems,
+		UniqueItems:      schema.UniqueItems,
+		MinProperties:    schema.MinProperties,
+		MaxProperties:    schema.MaxProperties,
+		Nullable:         schema.Nullable,
+	}
+
+	for _, value := range schema.Enum {
+		if s, ok := value.(string); ok {
+			v.Enum = append(v.Enum, s)
+			continue
+		}
+		j, err := json.Marshal(jsonCompatible(value))
+		if err == nil {
+			v.Enum = append(v.Enum, string(j))
+		}
+	}
+
+	for _, rule := range schema.XValidations {
+		v.Rules = append(v.Rules, ValidationRule{Rule: rule.Rule, Message: rule.Message})
+	}
+	return v
+}
+
+// jsonCompatible converts the maps decoded from YAML, whose keys can be
+// of any type, to maps which can be encoded in JSON
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			result[idx] = jsonCompatible(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// capitalize returns the passed name with the first letter in upper case,
+// i.e. `storage` becomes `Storage`
+func capitalize(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -22,63 +22,463 @@ limitations under the License.
 package parser
 
 import (
+	"errors"
 	"go/ast"
 	"go/doc"
-	"go/parser"
+	"go/scanner"
 	"go/token"
+	"strings"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
 )
 
-// GetKubeTypes return the k8s types into a slice
-func GetKubeTypes(filePaths []string) (KubeTypes, error) {
+// GetKubeTypes return the k8s types into a slice. The arguments can be
+// Go package patterns, like `./api/...`, or a list of files. The problems
+// found in the source code are returned as diagnostics, documenting what
+// can be documented, while an error is returned only when the packages
+// cannot be loaded at all
+func GetKubeTypes(args []string, buildTags []string) (KubeTypes, diagnostics.Diagnostics, error) {
+	var report diagnostics.Diagnostics
+
 	// Parse the input files or exit with an error state
 	fSet := token.NewFileSet()
-	m := make(map[string]*ast.File)
-	for _, filePath := range filePaths {
-		f, err := parser.ParseFile(fSet, filePath, nil, parser.ParseComments)
-		if err != nil {
-			return nil, err
+	packages, err := loadPackages(fSet, args, buildTags, &report)
+	if err != nil {
+		return nil, report, err
+	}
+
+	var docForTypes KubeTypes
+	for _, pkg := range packages {
+		// We don't need to fully type-check the code and we don't have access to
+		// all the types reachable by the code, so the only errors raised by the
+		// creation of the Package AST we consider are the redeclarations
+		apkg, err := ast.NewPackage(fSet, pkg.Files, nil, nil)
+		var errorList scanner.ErrorList
+		if errors.As(err, &errorList) {
+			for _, e := range errorList {
+				if strings.Contains(e.Msg, "redeclared") {
+					report.Errorf(relativePath(e.Pos.Filename), e.Pos.Line, e.Pos.Column, "declaration",
+						"%v", strings.ReplaceAll(e.Msg, "\n\t", "; "))
+				}
+			}
+		}
+
+		docForTypes = append(docForTypes,
+			newPackageParser(fSet, pkg.ImportPath, apkg, pkg.ImportNames, &report).getKubeTypes()...)
+	}
+
+	resolveReplacements(docForTypes)
+	report.Sort()
+	return docForTypes, report, nil
+}
+
+// packageParser contains the information about a package which is needed
+// while extracting the k8s types from it
+type packageParser struct {
+	fSet *token.FileSet
+	pkg  *ast.Package
+
+	// The package import path
+	packagePath string
+
+	// The scope used to resolve the types referenced in each
+	// file, indexed by file name
+	scopes map[string]typeScope
+
+	// The markers declared in the package documentation
+	packageMarkers markers
+
+	// Every structure declared in the package, exported or not,
+	// indexed by name
+	structTypes map[string]*ast.StructType
+
+	// The markers of every type, indexed by name
+	markersByType map[string]markers
+
+	// The constant declarations, in source order, with all their specs.
+	// They are copied as go/doc removes the unexported specs, which are
+	// needed to know the value of iota and to evaluate the constants
+	constDecls []*ast.GenDecl
+
+	// The specs of each constant declaration, as written in the source code
+	constSpecs map[*ast.GenDecl][]*ast.ValueSpec
+
+	// The names of the files excluded from the documentation
+	// via the `+docgen:hide` marker
+	hiddenFiles map[string]bool
+
+	// The names of the types synthesised for the anonymous structures
+	anonymousStructNames map[*ast.StructType]string
+
+	// The types synthesised for the anonymous structures
+	anonymousStructs []KubeStructure
+
+	// True while parsing an element excluded from the documentation via
+	// the `+docgen:hide` marker. The problems found in such elements are
+	// not reported, as they were not meant to be documented
+	hiding bool
+
+	// The problems found in the package
+	report *diagnostics.Diagnostics
+}
+
+// newPackageParser creates a parser for the given package
+func newPackageParser(
+	fSet *token.FileSet,
+	packagePath string,
+	apkg *ast.Package,
+	importNames map[string]string,
+	report *diagnostics.Diagnostics,
+) *packageParser {
+	p := &packageParser{
+		fSet:          fSet,
+		pkg:           apkg,
+		packagePath:   packagePath,
+		scopes:        make(map[string]typeScope),
+		structTypes:   make(map[string]*ast.StructType),
+		markersByType: make(map[string]markers),
+		constSpecs:    make(map[*ast.GenDecl][]*ast.ValueSpec),
+		hiddenFiles:   make(map[string]bool),
+
+		anonymousStructNames: make(map[*ast.StructType]string),
+		report:               report,
+	}
+
+	for _, fileName := range sortedFileNames(apkg.Files) {
+		f := apkg.Files[fileName]
+		p.scopes[fileName] = newTypeScope(packagePath, f, importNames)
+		p.packageMarkers = append(p.packageMarkers, fileMarkers(f)...)
+		if isHidden(fileMarkers(f)) {
+			p.hiddenFiles[fileName] = true
 		}
-		m[filePath] = f
+
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if ok && genDecl.Tok == token.CONST {
+				p.constDecls = append(p.constDecls, genDecl)
+				p.constSpecs[genDecl] = copyValueSpecs(genDecl.Specs)
+			}
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				p.markersByType[typeSpec.Name.Name] = typeMarkers(fSet, f, typeSpec, genDecl.Doc)
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+					p.structTypes[typeSpec.Name.Name] = structType
+				}
+			}
+		}
+	}
+
+	return p
+}
+
+// isDeclaredType returns whether a type is declared in the package,
+// including the ones without markers
+func (p *packageParser) isDeclaredType(name string) bool {
+	_, ok := p.markersByType[name]
+	return ok
+}
+
+// scopeOf returns the scope of the file containing the passed node
+func (p *packageParser) scopeOf(node ast.Node) typeScope {
+	return p.scopes[p.fSet.File(node.Pos()).Name()]
+}
+
+// warnf reports a problem found while parsing the passed node
+func (p *packageParser) warnf(node ast.Node, rule string, format string, args ...interface{}) {
+	if p.hiding {
+		return
 	}
+	pos := p.position(node.Pos())
+	p.report.Warnf(pos.Filename, pos.Line, pos.Column, rule, format, args...)
+}
 
-	// The errors raised by the creation of the Package AST are not considered as
-	// we don't need to fully type-check the code and we don't have access to all
-	// the types reachable by the code
-	apkg, _ := ast.NewPackage(fSet, m, nil, nil)
+// getKubeTypes extracts the documentation of the exported types. The types
+// and the fields excluded via the `+docgen:hide` marker are extracted too,
+// as they are part of the CRDs, and are marked as hidden
+func (p *packageParser) getKubeTypes() KubeTypes {
+	// go/doc removes the unexported declarations from the AST, including
+	// the `init` functions registering the types in the scheme and the
+	// unexported variables, so the AST is inspected before
+	groupVersion := p.getGroupVersion()
+	registeredTypes := p.getRegisteredTypes()
 
-	n := doc.New(apkg, "", 0)
+	// The AST is preserved because we need the unexported embedded structures
+	// to expand the inlined fields
+	n := doc.New(p.pkg, "", doc.PreserveAST)
+
+	// The constants can be associated to their type or, when a declaration
+	// contains values of different types, to the package
+	constants := n.Consts
+	for _, kubType := range n.Types {
+		constants = append(constants, kubType.Consts...)
+	}
+	enumValues := p.getEnumValues(constants)
 
 	var docForTypes KubeTypes
 
 	for _, kubType := range n.Types {
-		if structType, ok := kubType.Decl.Specs[0].(*ast.TypeSpec).Type.(*ast.StructType); ok {
-			kubeStructure := KubeStructure{
-				Name: kubType.Name,
-				Doc:  fmtRawDoc(kubType.Doc),
+		typeSpec := kubType.Decl.Specs[0].(*ast.TypeSpec)
+		p.hiding = isHidden(p.markersByType[kubType.Name]) || p.hiddenFiles[p.fSet.File(typeSpec.Pos()).Name()]
+
+		typeDoc, deprecation := getDeprecation(kubType.Doc, p.markersByType[kubType.Name])
+		kubeStructure := KubeStructure{
+			Name:         kubType.Name,
+			Doc:          fmtRawDoc(typeDoc),
+			DocTree:      parseDoc(p.scopeOf(typeSpec).docParser(), typeDoc),
+			RawDoc:       kubType.Doc,
+			Deprecation:  deprecation,
+			Annotations:  getAnnotations(p.markersByType[kubType.Name]),
+			Package:      p.packagePath,
+			Position:     p.position(typeSpec.Name.Pos()),
+			GroupVersion: groupVersion,
+			Validations:  p.getValidations(typeSpec, p.markersByType[kubType.Name]),
+			Hidden:       p.hiding,
+		}
+
+		switch typ := typeSpec.Type.(type) {
+		case *ast.StructType:
+			kubeStructure.Fields, kubeStructure.Inherits, kubeStructure.HiddenInherits = p.getKubeFields(
+				kubType.Name, typ, map[string]bool{kubType.Name: true})
+			kubeStructure.Resource = getResource(
+				kubeStructure, p.markersByType[kubType.Name], registeredTypes[kubType.Name])
+
+		case *ast.Ident, *ast.SelectorExpr, *ast.ArrayType, *ast.MapType,
+			*ast.StarExpr, *ast.ParenExpr, *ast.IndexExpr, *ast.IndexListExpr:
+			// Named types such as `type BackupMethod string` are documented
+			// together with the constants declaring their allowed values
+			underlying := p.fieldType(typ, kubType.Name)
+			kubeStructure.Underlying = &underlying
+			kubeStructure.Values = enumValues[kubType.Name]
+
+		default:
+			continue
+		}
+
+		docForTypes = append(docForTypes, kubeStructure)
+	}
+	p.hiding = false
+
+	for _, kubeStructure := range p.anonymousStructs {
+		kubeStructure.GroupVersion = groupVersion
+		docForTypes = append(docForTypes, kubeStructure)
+	}
+	return docForTypes
+}
+
+// copyValueSpecs copies the specs of a constant declaration, with
+// their lists of names and values
+func copyValueSpecs(specs []ast.Spec) []*ast.ValueSpec {
+	result := make([]*ast.ValueSpec, 0, len(specs))
+	for _, spec := range specs {
+		valueSpec := *spec.(*ast.ValueSpec)
+		valueSpec.Names = append([]*ast.Ident(nil), valueSpec.Names...)
+		valueSpec.Values = append([]ast.Expr(nil), valueSpec.Values...)
+		result = append(result, &valueSpec)
+	}
+	return result
+}
+
+// getEnumValues returns the typed constants declared in the passed
+// declarations, indexed by type name
+func (p *packageParser) getEnumValues(constants []*doc.Value) map[string][]KubeEnumValue {
+	result := make(map[string][]KubeEnumValue)
+	for _, constant := range constants {
+		var typeName string
+		var values []ast.Expr
+		specs := p.constSpecs[constant.Decl]
+		for specIndex, valueSpec := range specs {
+
+			// Constants without type and value repeat the previous ones
+			switch {
+			case valueSpec.Type != nil:
+				typeName = ""
+				if ident, ok := valueSpec.Type.(*ast.Ident); ok {
+					typeName = ident.Name
+				}
+				values = valueSpec.Values
+			case len(valueSpec.Values) > 0:
+				typeName = ""
+			}
+			if typeName == "" {
+				continue
+			}
+
+			valueDoc := valueSpec.Doc
+			if valueDoc == nil && len(specs) == 1 {
+				valueDoc = constant.Decl.Doc
+			}
+			if valueDoc == nil {
+				valueDoc = valueSpec.Comment
 			}
 
-			for _, field := range structType.Fields.List {
-				// TODO: manage inlined fields
-				if isInlined(field) {
-					// TODO: Ask Gabriele why typesDoc was not used
+			for idx, name := range valueSpec.Names {
+				if !name.IsExported() {
 					continue
 				}
+				var value ast.Expr
+				if idx < len(values) {
+					value = values[idx]
+				}
+				result[typeName] = append(result[typeName], KubeEnumValue{
+					Name:     name.Name,
+					Value:    p.constantValue(value, specIndex),
+					Doc:      fmtRawDoc(valueDoc.Text()),
+					DocTree:  parseDoc(p.scopeOf(name).docParser(), valueDoc.Text()),
+					RawDoc:   valueDoc.Text(),
+					Position: p.position(name.Pos()),
+				})
+			}
+		}
+	}
+	return result
+}
 
-				typeInfo := fieldType(field.Type)
-				fieldMandatory := fieldRequired(field)
-				if n := fieldName(field); n != "-" {
-					fieldDoc := fmtRawDoc(field.Doc.Text())
-					kubeStructure.Fields = append(kubeStructure.Fields,
-						KubeField{
-							Name:      n,
-							Type:      typeInfo,
-							Doc:       fieldDoc,
-							Mandatory: fieldMandatory,
-						})
+// getKubeFields returns the fields of a structure, including the ones promoted
+// from inlined local structures, and the lists of external types whose fields
+// are inherited, split between the visible and the hidden ones. The visiting
+// map is used to detect cycles between inlined structures.
+func (p *packageParser) getKubeFields(
+	structName string,
+	structType *ast.StructType,
+	visiting map[string]bool,
+) ([]KubeField, []TypeInfo, []TypeInfo) {
+	// The fields declared directly in the structure take precedence
+	// over the promoted ones, as in encoding/json
+	ownFields := make(map[string]bool)
+	for _, field := range structType.Fields.List {
+		if isInlined(field) {
+			continue
+		}
+		for _, name := range fieldNames(field) {
+			if isExported(name) {
+				ownFields[fieldName(field, name)] = true
+			}
+		}
+	}
+
+	defaultRequired := p.isRequiredByDefault(structName)
+
+	// Everything found inside a hidden element is hidden too
+	hiding := p.hiding
+	defer func() {
+		p.hiding = hiding
+	}()
+
+	var fields []KubeField
+	var inherits, hiddenInherits []TypeInfo
+	promotedFrom := make(map[string]string)
+	for _, field := range structType.Fields.List {
+		fieldMarkers := extractMarkers(field.Doc)
+		p.hiding = hiding || isHidden(fieldMarkers)
+
+		if isInlined(field) {
+			typeInfo := p.fieldType(field.Type, structName+embeddedTypeName(field.Type))
+			embeddedStruct, isLocal := p.structTypes[typeInfo.BaseType]
+			if !typeInfo.Internal || !isLocal {
+				// We don't have the source of this type, so we can
+				// only tell the reader where the fields come from
+				if p.hiding {
+					hiddenInherits = append(hiddenInherits, typeInfo)
+				} else {
+					inherits = append(inherits, typeInfo)
 				}
+				continue
 			}
-			docForTypes = append(docForTypes, kubeStructure)
+
+			if visiting[typeInfo.BaseType] {
+				p.warnf(field, "inline-cycle", "skipping %v, which is recursively inlined in %v",
+					typeInfo.BaseType, structName)
+				continue
+			}
+			visiting[typeInfo.BaseType] = true
+			promotedFields, promotedInherits, promotedHiddenInherits := p.getKubeFields(
+				typeInfo.BaseType, embeddedStruct, visiting)
+			delete(visiting, typeInfo.BaseType)
+
+			inherits = append(inherits, promotedInherits...)
+			hiddenInherits = append(hiddenInherits, promotedHiddenInherits...)
+			for _, promotedField := range promotedFields {
+				if promotedField.Hidden {
+					// The hidden fields don't take part in the conflicts,
+					// as they were ignored before being hidden
+					if !ownFields[promotedField.Name] && promotedFrom[promotedField.Name] == "" {
+						fields = append(fields, promotedField)
+					}
+					continue
+				}
+				if ownFields[promotedField.Name] {
+					p.warnf(field, "shadowed-field", "field %v promoted from %v is shadowed by a field of %v",
+						promotedField.Name, typeInfo.BaseType, structName)
+					continue
+				}
+				if previous, ok := promotedFrom[promotedField.Name]; ok {
+					p.warnf(field, "conflicting-field", "field %v promoted from %v conflicts with the one promoted from %v",
+						promotedField.Name, typeInfo.BaseType, previous)
+					continue
+				}
+				promotedFrom[promotedField.Name] = typeInfo.BaseType
+				fields = append(fields, promotedField)
+			}
+			continue
+		}
+
+		for _, name := range fieldNames(field) {
+			if !isExported(name) {
+				continue
+			}
+
+			n := fieldName(field, name)
+			if n == "-" {
+				continue
+			}
+
+			goName := embeddedTypeName(field.Type)
+			namePos := field.Type.Pos()
+			if name != nil {
+				goName = name.Name
+				namePos = name.Pos()
+			}
+			typeInfo := p.fieldType(field.Type, structName+goName)
+			fieldMandatory := fieldRequired(field, fieldMarkers, defaultRequired)
+			fieldDoc, deprecation := getDeprecation(field.Doc.Text(), fieldMarkers)
+			fields = append(fields,
+				KubeField{
+					Name:        n,
+					GoName:      goName,
+					Type:        typeInfo,
+					Doc:         fmtRawDoc(fieldDoc),
+					DocTree:     parseDoc(p.scopeOf(field).docParser(), fieldDoc),
+					RawDoc:      field.Doc.Text(),
+					Deprecation: deprecation,
+					Annotations: getAnnotations(fieldMarkers),
+					Mandatory:   fieldMandatory,
+					OmitEmpty:   isOmitEmpty(field),
+					Validations: p.getValidations(field, fieldMarkers),
+					Default:     getDefault(fieldMarkers),
+					Position:    p.position(namePos),
+					Hidden:      p.hiding,
+				})
+		}
+	}
+	return fields, inherits, hiddenInherits
+}
+
+// isRequiredByDefault returns whether the fields of a structure without an
+// explicit optional or required marker are required. As in controller-gen,
+// this can be changed with the `+kubebuilder:validation:Optional` and
+// `+kubebuilder:validation:Required` markers on the structure or, if none is
+// present, on the package.
+func (p *packageParser) isRequiredByDefault(structName string) bool {
+	for _, m := range []markers{p.markersByType[structName], p.packageMarkers} {
+		switch {
+		case m.has(optionalValidationMarker):
+			return false
+		case m.has(requiredValidationMarker):
+			return true
 		}
 	}
-	return docForTypes, nil
+	return true
 }
@@ -0,0 +1,374 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+)
+
+const testPackagePath = "example.com/api/v1"
+
+// parseSource extracts the types declared in the passed source files,
+// which are named after their position in the list
+func parseSource(t *testing.T, sources ...string) (KubeTypes, diagnostics.Diagnostics) {
+	t.Helper()
+
+	var report diagnostics.Diagnostics
+	fSet := token.NewFileSet()
+	files := make(map[string]*ast.File)
+	for idx, source := range sources {
+		fileName := string(rune('a'+idx)) + "_types.go"
+		f, err := parser.ParseFile(fSet, fileName, source, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("cannot parse the source: %v", err)
+		}
+		files[fileName] = f
+	}
+
+	apkg, _ := ast.NewPackage(fSet, files, nil, nil)
+	importNames := map[string]string{
+		"k8s.io/apimachinery/pkg/apis/meta/v1":   "v1",
+		"k8s.io/apimachinery/pkg/runtime/schema": "schema",
+		"github.com/go-logr/logr/v2":             "logr",
+	}
+	kt := newPackageParser(fSet, testPackagePath, apkg, importNames, &report).getKubeTypes()
+	resolveReplacements(kt)
+	return kt, report
+}
+
+// findType returns the type with the passed name
+func findType(t *testing.T, kt KubeTypes, name string) KubeStructure {
+	t.Helper()
+	for _, kubeStructure := range kt {
+		if kubeStructure.Name == name {
+			return kubeStructure
+		}
+	}
+	t.Fatalf("type %v not found", name)
+	return KubeStructure{}
+}
+
+func TestGetKubeFields(t *testing.T) {
+	type field struct {
+		name      string
+		goName    string
+		typeName  string
+		mandatory bool
+		hidden    bool
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		expected []field
+		inherits []string
+	}{
+		{
+			name: "json and go names",
+			source: `package v1
+type Spec struct {
+	// ImageName is the image
+	ImageName string ` + "`json:\"image\"`" + `
+	// +optional
+	Replicas *int32 ` + "`json:\"replicas,omitempty\"`" + `
+	Ignored string ` + "`json:\"-\"`" + `
+	unexported string
+}`,
+			expected: []field{
+				{name: "image", goName: "ImageName", typeName: "string", mandatory: true},
+				{name: "replicas", goName: "Replicas", typeName: "*int32"},
+			},
+		},
+		{
+			name: "optional and required markers",
+			source: `package v1
+// +kubebuilder:validation:Optional
+type Spec struct {
+	Image string ` + "`json:\"image\"`" + `
+	// +kubebuilder:validation:Required
+	Size string ` + "`json:\"size,omitempty\"`" + `
+}`,
+			expected: []field{
+				{name: "image", goName: "Image", typeName: "string"},
+				{name: "size", goName: "Size", typeName: "string", mandatory: true},
+			},
+		},
+		{
+			name: "hidden fields are kept and marked",
+			source: `package v1
+type Spec struct {
+	Public string ` + "`json:\"public\"`" + `
+	// +docgen:hide
+	Internal string ` + "`json:\"internal\"`" + `
+}`,
+			expected: []field{
+				{name: "public", goName: "Public", typeName: "string", mandatory: true},
+				{name: "internal", goName: "Internal", typeName: "string", mandatory: true, hidden: true},
+			},
+		},
+		{
+			name: "inlined structures",
+			source: `package v1
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+type Common struct {
+	Image string ` + "`json:\"image\"`" + `
+}
+type Spec struct {
+	metav1.TypeMeta ` + "`json:\",inline\"`" + `
+	Common ` + "`json:\",inline\"`" + `
+	Size string ` + "`json:\"size\"`" + `
+}`,
+			expected: []field{
+				{name: "image", goName: "Image", typeName: "string", mandatory: true},
+				{name: "size", goName: "Size", typeName: "string", mandatory: true},
+			},
+			inherits: []string{"metav1.TypeMeta"},
+		},
+		{
+			name: "embedded structures without a JSON name",
+			source: `package v1
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+type Common struct {
+	Image string ` + "`json:\"image\"`" + `
+}
+type Spec struct {
+	Common
+	metav1.ObjectMeta ` + "`protobuf:\"bytes,1,opt\"`" + `
+	Size string ` + "`json:\"size\"`" + `
+}`,
+			expected: []field{
+				{name: "image", goName: "Image", typeName: "string", mandatory: true},
+				{name: "size", goName: "Size", typeName: "string", mandatory: true},
+			},
+			inherits: []string{"metav1.ObjectMeta"},
+		},
+		{
+			name: "names containing inline",
+			source: `package v1
+type Common struct {
+	Image string ` + "`json:\"image\"`" + `
+}
+type Spec struct {
+	InlineName string ` + "`json:\"inlineName\"`" + `
+	Common ` + "`json:\"common,omitempty\"`" + `
+}`,
+			expected: []field{
+				{name: "inlineName", goName: "InlineName", typeName: "string", mandatory: true},
+				{name: "common", goName: "Common", typeName: "Common"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kt, _ := parseSource(t, tt.source)
+			spec := findType(t, kt, "Spec")
+
+			var fields []field
+			for _, f := range spec.Fields {
+				fields = append(fields, field{
+					name:      f.Name,
+					goName:    f.GoName,
+					typeName:  f.Type.Name,
+					mandatory: f.Mandatory,
+					hidden:    f.Hidden,
+				})
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("expected fields %+v, got %+v", tt.expected, fields)
+			}
+
+			var inherits []string
+			for _, inherited := range spec.Inherits {
+				inherits = append(inherits, inherited.Name)
+			}
+			if !reflect.DeepEqual(inherits, tt.inherits) {
+				t.Errorf("expected inherited types %v, got %v", tt.inherits, inherits)
+			}
+		})
+	}
+}
+
+func TestFieldType(t *testing.T) {
+	tests := []struct {
+		name        string
+		fieldType   string
+		kind        TypeKind
+		typeName    string
+		packagePath string
+	}{
+		{name: "builtin", fieldType: "string", kind: TypeKindNamed, typeName: "string"},
+		{name: "local", fieldType: "Spec", kind: TypeKindNamed, typeName: "Spec", packagePath: testPackagePath},
+		{
+			name:        "imported with an alias",
+			fieldType:   "metav1.Time",
+			kind:        TypeKindNamed,
+			typeName:    "metav1.Time",
+			packagePath: "k8s.io/apimachinery/pkg/apis/meta/v1",
+		},
+		{
+			name:        "imported without alias, named differently from the path",
+			fieldType:   "logr.Logger",
+			kind:        TypeKindNamed,
+			typeName:    "logr.Logger",
+			packagePath: "github.com/go-logr/logr/v2",
+		},
+		{name: "pointer", fieldType: "*Spec", kind: TypeKindPointer, typeName: "*Spec", packagePath: testPackagePath},
+		{name: "slice", fieldType: "[]string", kind: TypeKindSlice, typeName: "[]string"},
+		{name: "map", fieldType: "map[string]int", kind: TypeKindMap, typeName: "map[string]int"},
+		{name: "interface", fieldType: "interface{}", kind: TypeKindInterface, typeName: "interface{}"},
+		{name: "channel", fieldType: "chan int", kind: TypeKindChan, typeName: "chan int"},
+		{name: "array", fieldType: "[2]int", kind: TypeKindArray, typeName: "[2]int"},
+		{name: "function", fieldType: "func()", kind: TypeKindFunc, typeName: "func()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kt, _ := parseSource(t, `package v1
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/go-logr/logr/v2"
+)
+type Spec struct {
+	Field `+tt.fieldType+` `+"`json:\"field\"`"+`
+}`)
+			fieldType := findType(t, kt, "Spec").Fields[0].Type
+			if fieldType.Kind != tt.kind {
+				t.Errorf("expected kind %q, got %q", tt.kind, fieldType.Kind)
+			}
+			if fieldType.Name != tt.typeName {
+				t.Errorf("expected name %q, got %q", tt.typeName, fieldType.Name)
+			}
+			if fieldType.Package != tt.packagePath {
+				t.Errorf("expected package %q, got %q", tt.packagePath, fieldType.Package)
+			}
+		})
+	}
+}
+
+func TestNestedFieldType(t *testing.T) {
+	kt, _ := parseSource(t, `package v1
+type Spec struct {
+	Field map[string][]*Spec `+"`json:\"field\"`"+`
+}`)
+	fieldType := findType(t, kt, "Spec").Fields[0].Type
+	if fieldType.Key == nil || fieldType.Key.Name != "string" {
+		t.Errorf("expected the key type string, got %+v", fieldType.Key)
+	}
+
+	var constructors []string
+	for info := &fieldType; info != nil; info = info.Elem {
+		constructors = append(constructors, info.Constructor)
+		if info.BaseType != "Spec" {
+			t.Errorf("expected the base type Spec, got %q in %q", info.BaseType, info.Name)
+		}
+	}
+	expected := []string{"map[string]", "[]", "*", ""}
+	if !reflect.DeepEqual(constructors, expected) {
+		t.Errorf("expected the constructors %q, got %q", expected, constructors)
+	}
+}
+
+func TestAnonymousStructType(t *testing.T) {
+	kt, _ := parseSource(t, `package v1
+type SpecTemplate struct {
+	Image string `+"`json:\"image\"`"+`
+}
+type Spec struct {
+	Template struct {
+		Size string `+"`json:\"size\"`"+`
+	} `+"`json:\"template\"`"+`
+	Other struct {
+		Size string `+"`json:\"size\"`"+`
+	} `+"`json:\"other\"`"+`
+}`)
+
+	expected := map[string]string{"template": "SpecTemplate2", "other": "SpecOther"}
+	for _, field := range findType(t, kt, "Spec").Fields {
+		if field.Type.Kind != TypeKindStruct || field.Type.Name != expected[field.Name] {
+			t.Errorf("expected field %v to be the structure %v, got %+v", field.Name, expected[field.Name], field.Type)
+		}
+		if anonymous := findType(t, kt, field.Type.Name); !anonymous.Anonymous {
+			t.Errorf("expected %v to be anonymous", field.Type.Name)
+		}
+	}
+	if declared := findType(t, kt, "SpecTemplate"); declared.Anonymous || declared.Fields[0].Name != "image" {
+		t.Errorf("expected SpecTemplate to be the declared type, got %+v", declared)
+	}
+}
+
+func TestUnsupportedTypeKind(t *testing.T) {
+	var report diagnostics.Diagnostics
+	p := &packageParser{report: &report}
+	info := p.fieldType(&ast.Ellipsis{Elt: ast.NewIdent("int")}, "Spec")
+	if info.Kind != TypeKindUnsupported {
+		t.Errorf("expected kind %q, got %q", TypeKindUnsupported, info.Kind)
+	}
+	if report.Count(diagnostics.SeverityWarning) != 1 {
+		t.Errorf("expected a warning, got %v", report)
+	}
+}
+
+func TestNewTypeScope(t *testing.T) {
+	tests := []struct {
+		name        string
+		imports     string
+		importNames map[string]string
+		expected    map[string]string
+	}{
+		{
+			name:     "explicit names",
+			imports:  `import corev1 "k8s.io/api/core/v1"`,
+			expected: map[string]string{"corev1": "k8s.io/api/core/v1"},
+		},
+		{
+			name:        "names read from the go command",
+			imports:     `import ("github.com/go-logr/logr/v2"; "gopkg.in/yaml.v3"; "github.com/mattn/go-isatty")`,
+			importNames: map[string]string{"github.com/go-logr/logr/v2": "logr", "gopkg.in/yaml.v3": "yaml", "github.com/mattn/go-isatty": "isatty"},
+			expected:    map[string]string{"logr": "github.com/go-logr/logr/v2", "yaml": "gopkg.in/yaml.v3", "isatty": "github.com/mattn/go-isatty"},
+		},
+		{
+			name:     "unknown names are not guessed",
+			imports:  `import "example.com/api/v2"`,
+			expected: map[string]string{},
+		},
+		{
+			name:     "blank and dot imports",
+			imports:  `import (_ "embed"; . "strings")`,
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "types.go", "package v1\n"+tt.imports, 0)
+			if err != nil {
+				t.Fatalf("cannot parse the source: %v", err)
+			}
+			scope := newTypeScope(testPackagePath, f, tt.importNames)
+			if !reflect.DeepEqual(scope.imports, tt.expected) {
+				t.Errorf("expected imports %v, got %v", tt.expected, scope.imports)
+			}
+		})
+	}
+}
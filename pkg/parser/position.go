@@ -0,0 +1,84 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Position is the place in the source code where an element is declared
+type Position struct {
+	// The file path, relative to the working directory when the file
+	// is inside it and absolute otherwise
+	Filename string
+
+	// The line number, starting at 1
+	Line int
+
+	// The column number, starting at 1
+	Column int
+}
+
+// IsValid returns whether the position is known
+func (pos Position) IsValid() bool {
+	return pos.Line > 0
+}
+
+// String returns the position in the `file:line:column` format used by
+// the Go tools, or "-" when the position is not known
+func (pos Position) String() string {
+	if !pos.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%v:%v:%v", pos.Filename, pos.Line, pos.Column)
+}
+
+// position returns the position in the source code of the passed Pos
+func (p *packageParser) position(pos token.Pos) Position {
+	position := p.fSet.Position(pos)
+	return Position{
+		Filename: relativePath(position.Filename),
+		Line:     position.Line,
+		Column:   position.Column,
+	}
+}
+
+// relativePath makes a path relative to the working directory,
+// leaving it untouched if the file is outside of it
+func relativePath(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return absPath
+	}
+	return rel
+}
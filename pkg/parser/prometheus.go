@@ -23,6 +23,8 @@ import (
 	"go/ast"
 	"reflect"
 	"strings"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/doctree"
 )
 
 // -------------------------------------------------------------------------------------------------------------------
@@ -35,23 +37,59 @@ type KubeField struct {
 	// The field name (in the JSON representation of this object)
 	Name string
 
+	// The field name in the Go source code, empty when the field
+	// has not been read from it
+	GoName string
+
 	// The field type
 	Type TypeInfo
 
 	// The normalized documentation
 	Doc string
 
+	// The documentation, parsed with the Go doc comment syntax
+	DocTree doctree.Document
+
+	// The doc comment, as written in the source code
+	RawDoc string
+
 	// Mandatory flag
 	Mandatory bool
+
+	// True if the field is omitted from the JSON representation when
+	// empty, via the `omitempty` or `omitzero` options
+	OmitEmpty bool
+
+	// The constraints declared via validation markers
+	Validations Validations
+
+	// The JSON representation of the default value, or an empty
+	// string if the field has no default
+	Default string
+
+	// Whether the field is deprecated
+	Deprecation
+
+	// The annotations declared via the `+docgen:` markers
+	Annotations
+
+	// Where the field is declared
+	Position Position
+
+	// True if the field is excluded from the documentation via the
+	// `+docgen:hide` marker, or belongs to an excluded element
+	Hidden bool
 }
 
 // TypeInfo is a struct representing a type with a given name and it's base type name.
 // I.e. a type named `[]Pod` has `Pod` as a base type. Atomic types have `Name == BaseName`.
 //
-// We are adopting a simplification here: we consider only type constructors with 1 parameter.
-// The only multiple-arity type constructor we have is `map[T1]T2` and, since kubernetes
-// resources must be JSON-serializable, T1 == string. Given that, T1 is not interesting and
-// we are only using T2 as base type.
+// Types built with type constructors are represented as a tree where each level
+// describes one constructor and refers to the type it is built on through Elem,
+// until the named base type is reached. I.e. `map[string][]*Pod` is a map whose
+// Key is `string` and whose Elem is `[]*Pod`, which in turn is a slice of `*Pod`,
+// a pointer to `Pod`. The BaseType of every level is the innermost named type of
+// the Elem chain, `Pod` in the example, which is the one to be linked.
 type TypeInfo struct {
 	// The type name (i.e. `[]Pod`)
 	Name string
@@ -59,11 +97,64 @@ type TypeInfo struct {
 	// The base type name (i.e. `Pod`)
 	BaseType string
 
-	// The type-constructor who generated the type (i.e. `[]`)
+	// The type-constructor who generated the type (i.e. `[]` or
+	// `map[string]`), empty for named types
 	Constructor string
 
 	// True if the type is internal to this package and false otherwise
 	Internal bool
+
+	// The import path of the package declaring the base type
+	// (i.e. `k8s.io/api/core/v1`), empty for builtin types
+	Package string
+
+	// The kind of type expression
+	Kind TypeKind
+
+	// The type this one is built on (i.e. `*Pod` for `[]*Pod`, or
+	// `Pod` for `map[string]Pod`), nil for named types
+	Elem *TypeInfo
+
+	// The key type of a map (i.e. `string` for `map[string]Pod`)
+	Key *TypeInfo
+
+	// The type arguments of a generic type instantiation
+	// (i.e. `Pod` for `List[Pod]`)
+	TypeArgs []TypeInfo
+}
+
+// QualifiedName returns the name of the base type qualified with the
+// import path of its package (i.e. `k8s.io/api/core/v1.SecretKeySelector`),
+// which doesn't depend on the name used to import the package
+func (info TypeInfo) QualifiedName() string {
+	baseType := strings.TrimLeft(info.BaseType, "*")
+	if info.Package == "" {
+		return baseType
+	}
+
+	if idx := strings.LastIndex(baseType, "."); idx >= 0 {
+		baseType = baseType[idx+1:]
+	}
+	return info.Package + "." + baseType
+}
+
+// NamedTypes returns the named types this type is built on, including
+// the map keys and the type arguments. I.e. `map[string][]*Pod` is built
+// on `string` and `Pod`
+func (info TypeInfo) NamedTypes() []TypeInfo {
+	var result []TypeInfo
+	if info.Key != nil {
+		result = append(result, info.Key.NamedTypes()...)
+	}
+	if info.Elem != nil {
+		result = append(result, info.Elem.NamedTypes()...)
+	} else {
+		result = append(result, info)
+	}
+	for _, typeArg := range info.TypeArgs {
+		result = append(result, typeArg.NamedTypes()...)
+	}
+	return result
 }
 
 // KubeStructure represent a structure that we need to document
@@ -74,13 +165,134 @@ type KubeStructure struct {
 	// The normalized documentation
 	Doc string
 
-	// The structure fields
+	// The documentation, parsed with the Go doc comment syntax
+	DocTree doctree.Document
+
+	// The doc comment, as written in the source code
+	RawDoc string
+
+	// The import path of the package declaring the structure, empty
+	// when the structure has been loaded from a list of files
+	Package string
+
+	// The API group and version of the structure
+	GroupVersion GroupVersion
+
+	// The resource metadata, if this is a root object
+	Resource *KubeResource
+
+	// Whether the structure, or its API version, is deprecated
+	Deprecation
+
+	// The annotations declared via the `+docgen:` markers
+	Annotations
+
+	// The constraints declared via validation markers
+	Validations Validations
+
+	// The structure fields, including the ones promoted from
+	// inlined structures
 	Fields []KubeField
+
+	// The external types which are inlined in this structure and whose
+	// fields are inherited
+	Inherits []TypeInfo
+
+	// The external types which are inlined via hidden fields
+	HiddenInherits []TypeInfo
+
+	// The underlying type, if this is not a structure but a named type
+	// such as `type BackupMethod string`
+	Underlying *TypeInfo
+
+	// The values declared as typed constants, if this is a named type
+	Values []KubeEnumValue
+
+	// True if this type has been synthesised from an anonymous structure
+	Anonymous bool
+
+	// Where the structure is declared
+	Position Position
+
+	// True if the structure is excluded from the documentation via the
+	// `+docgen:hide` marker. The hidden types and fields are only used
+	// to compare the Go types with the CRDs, see WithoutHidden
+	Hidden bool
+}
+
+// KubeEnumValue is a value of a named type declared as a typed constant
+type KubeEnumValue struct {
+	// The constant name
+	Name string
+
+	// The constant value, unquoted if it is a string
+	Value string
+
+	// The normalized documentation
+	Doc string
+
+	// The documentation, parsed with the Go doc comment syntax
+	DocTree doctree.Document
+
+	// The doc comment, as written in the source code
+	RawDoc string
+
+	// Where the constant is declared
+	Position Position
+}
+
+// References returns the named types referenced by the structure, via
+// its fields, its inherited types or its underlying type
+func (kubeStructure KubeStructure) References() []TypeInfo {
+	var result []TypeInfo
+	for _, field := range kubeStructure.Fields {
+		result = append(result, field.Type.NamedTypes()...)
+	}
+	for _, inherited := range kubeStructure.Inherits {
+		result = append(result, inherited.NamedTypes()...)
+	}
+	if kubeStructure.Underlying != nil {
+		result = append(result, kubeStructure.Underlying.NamedTypes()...)
+	}
+	return result
+}
+
+// QualifiedName returns the name of the structure qualified with the
+// import path of its package, matching TypeInfo.QualifiedName
+func (kubeStructure KubeStructure) QualifiedName() string {
+	if kubeStructure.Package == "" {
+		return kubeStructure.Name
+	}
+	return kubeStructure.Package + "." + kubeStructure.Name
 }
 
 // KubeTypes is an array to represent all available types in a parsed file. [0] is for the type itself
 type KubeTypes []KubeStructure
 
+// WithoutHidden returns the types to be documented, removing the types
+// and the fields excluded via the `+docgen:hide` marker
+func (kt KubeTypes) WithoutHidden() KubeTypes {
+	result := make(KubeTypes, 0, len(kt))
+	for _, kubeStructure := range kt {
+		if kubeStructure.Hidden {
+			continue
+		}
+
+		fields := make([]KubeField, 0, len(kubeStructure.Fields))
+		for _, field := range kubeStructure.Fields {
+			if !field.Hidden {
+				fields = append(fields, field)
+			}
+		}
+		if kubeStructure.Fields != nil {
+			kubeStructure.Fields = fields
+		}
+		kubeStructure.HiddenInherits = nil
+		result = append(result, kubeStructure)
+	}
+	return result
+}
+
 func fmtRawDoc(rawDoc string) string {
 	var buffer bytes.Buffer
 	delPrevChar := func() {
@@ -90,7 +302,7 @@ func fmtRawDoc(rawDoc string) string {
 	}
 
 	// Ignore all lines after ---
-	rawDoc = strings.Split(rawDoc, "---")[0]
+	rawDoc = cutAtSeparator(rawDoc)
 
 	for _, line := range strings.Split(rawDoc, "\n") {
 		line = strings.TrimRight(line, " ")
@@ -121,18 +333,44 @@ func fmtRawDoc(rawDoc string) string {
 	return strings.TrimRight(buffer.String(), "\n")
 }
 
+// isInlined returns whether the fields of an embedded structure are
+// promoted in the JSON representation. This happens when the "inline"
+// option is used or when the JSON name of an embedded field is empty
 func isInlined(field *ast.Field) bool {
+	jsonTag := ""
 	if field.Tag != nil {
-		jsonTag := reflect.StructTag(
+		jsonTag = reflect.StructTag(
 			field.Tag.Value[1 : len(field.Tag.Value)-1]).Get("json") // Delete first and last quotation
-		return strings.Contains(jsonTag, "inline")
 	}
-	return false
+	jsonOptions := strings.Split(jsonTag, ",")
+	for _, option := range jsonOptions[1:] {
+		if option == "inline" {
+			return true
+		}
+	}
+	return field.Names == nil && jsonOptions[0] == ""
+}
+
+// isExported returns whether a field name is part of the JSON representation
+// according to its visibility. Embedded fields are always considered as
+// the exported fields of unexported structures are promoted too
+func isExported(name *ast.Ident) bool {
+	return name == nil || name.IsExported()
+}
+
+// fieldNames returns the names declared by a field, i.e. `A` and `B` for
+// `A, B int`. Embedded fields have a single nil name.
+func fieldNames(field *ast.Field) []*ast.Ident {
+	if field.Names == nil {
+		return []*ast.Ident{nil}
+	}
+	return field.Names
 }
 
 // fieldName returns the name of the field as it should appear in JSON format
-// "-" indicates that this field is not part of the JSON representation
-func fieldName(field *ast.Field) string {
+// "-" indicates that this field is not part of the JSON representation.
+// The name is nil for embedded fields.
+func fieldName(field *ast.Field, name *ast.Ident) string {
 	// If json tag does not exists, we use the name instead
 	jsonTag := ""
 	if field.Tag != nil {
@@ -144,65 +382,55 @@ func fieldName(field *ast.Field) string {
 	// This can return "-"
 	jsonTag = strings.Split(jsonTag, ",")[0]
 	if jsonTag == "" {
-		if field.Names != nil {
-			return field.Names[0].Name
+		if name != nil {
+			return name.Name
 		}
-		return field.Type.(*ast.Ident).Name
+		return embeddedTypeName(field.Type)
 	}
 	return jsonTag
 }
 
-// fieldRequired returns whether a field is a required field.
-func fieldRequired(field *ast.Field) bool {
+// The markers used to declare a field as optional or required
+const (
+	optionalMarker           = "optional"
+	requiredMarker           = "required"
+	optionalValidationMarker = "kubebuilder:validation:Optional"
+	requiredValidationMarker = "kubebuilder:validation:Required"
+)
+
+// fieldRequired returns whether a field is a required field. The explicit
+// markers on the field take precedence, then the default of the structure
+// or package applies. When fields are required by default, as in
+// controller-gen, the ones marked with `omitempty` are optional.
+func fieldRequired(field *ast.Field, fieldMarkers markers, defaultRequired bool) bool {
+	switch {
+	case fieldMarkers.has(optionalValidationMarker):
+		return false
+	case fieldMarkers.has(requiredValidationMarker):
+		return true
+	case fieldMarkers.has(optionalMarker):
+		return false
+	case fieldMarkers.has(requiredMarker):
+		return true
+	case !defaultRequired:
+		return false
+	}
+
+	return !isOmitEmpty(field)
+}
+
+// isOmitEmpty returns whether a field is omitted from the JSON
+// representation when empty, via the `omitempty` or `omitzero` options
+func isOmitEmpty(field *ast.Field) bool {
 	jsonTag := ""
 	if field.Tag != nil {
 		jsonTag = reflect.StructTag(
 			field.Tag.Value[1 : len(field.Tag.Value)-1]).Get("json") // Delete first and last quotation
-		return !strings.Contains(jsonTag, "omitempty")
 	}
-
-	return false
-}
-
-func fieldType(typ ast.Expr) TypeInfo {
-	switch ft := typ.(type) {
-	case *ast.Ident:
-		return TypeInfo{
-			Name:        ft.Name,
-			BaseType:    ft.Name,
-			Constructor: "",
-			Internal:    true,
+	for _, option := range strings.Split(jsonTag, ",")[1:] {
+		if option == "omitempty" || option == "omitzero" {
+			return true
 		}
-	case *ast.StarExpr:
-		return TypeInfo{
-			Name:        "*" + fieldType(ft.X).Name,
-			BaseType:    fieldType(ft.X).Name,
-			Constructor: "*",
-			Internal:    fieldType(ft.X).Internal,
-		}
-	case *ast.SelectorExpr:
-		pkg := ft.X.(*ast.Ident)
-		return TypeInfo{
-			Name:        pkg.Name + "." + ft.Sel.Name,
-			BaseType:    pkg.Name + "." + ft.Sel.Name,
-			Constructor: "",
-			Internal:    false,
-		}
-	case *ast.ArrayType:
-		return TypeInfo{
-			Name:        "[]" + fieldType(ft.Elt).Name,
-			BaseType:    fieldType(ft.Elt).Name,
-			Constructor: "[]",
-			Internal:    fieldType(ft.Elt).Internal,
-		}
-	case *ast.MapType:
-		return TypeInfo{
-			Name:        fmt.Sprintf("map[%v]%v", fieldType(ft.Key).Name, fieldType(ft.Value).Name),
-			BaseType:    fieldType(ft.Value).Name,
-			Constructor: "map[]",
-			Internal:    fieldType(ft.Value).Internal,
-		}
-	default:
-		return TypeInfo{}
 	}
+	return false
 }
@@ -0,0 +1,248 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// KubeResource contains the metadata of a root object, a type which can be
+// stored in the API server as a resource of a certain Kind
+type KubeResource struct {
+	// The Kind, which is the name of the structure
+	Kind string
+
+	// True if this is the list of a Kind, i.e. `ClusterList`
+	List bool
+
+	// `Namespaced` or `Cluster`, empty for the lists
+	Scope string
+
+	// The plural name of the resource, used in the API paths, empty
+	// for the lists
+	Plural string
+
+	// The singular name of the resource, empty for the lists
+	Singular string
+
+	// The short names which can be used with kubectl
+	ShortNames []string
+
+	// The categories of the resource, i.e. `all`
+	Categories []string
+
+	// The additional columns shown by `kubectl get`
+	PrintColumns []PrintColumn
+
+	// The subresources served by the API server
+	Subresources Subresources
+}
+
+// PrintColumn is an additional column shown by `kubectl get`, as declared
+// with the `+kubebuilder:printcolumn` marker
+type PrintColumn struct {
+	// The column header
+	Name string
+
+	// The OpenAPI type of the column, i.e. `integer` or `date`
+	Type string
+
+	// The JSON path of the shown field, i.e. `.status.phase`
+	JSONPath string
+
+	// The human-readable description of the column
+	Description string
+
+	// The OpenAPI format of the column, i.e. `byte`
+	Format string
+
+	// The priority of the column. Columns with a priority greater
+	// than 0 are only shown in the wide view
+	Priority int
+}
+
+// Subresources are the subresources served by the API server for a Kind
+type Subresources struct {
+	// True if the `status` subresource is enabled
+	Status bool
+
+	// The `scale` subresource, nil if not enabled
+	Scale *ScaleSubresource
+}
+
+// ScaleSubresource describes the `scale` subresource of a Kind
+type ScaleSubresource struct {
+	// The JSON path of the desired replicas, i.e. `.spec.replicas`
+	SpecReplicasPath string
+
+	// The JSON path of the observed replicas, i.e. `.status.replicas`
+	StatusReplicasPath string
+
+	// The JSON path of the label selector used by the HorizontalPodAutoscaler
+	LabelSelectorPath string
+}
+
+const (
+	// ScopeNamespaced is the scope of the resources living in a namespace
+	ScopeNamespaced = "Namespaced"
+
+	// ScopeCluster is the scope of the resources not living in a namespace
+	ScopeCluster = "Cluster"
+)
+
+// getRegisteredTypes returns the names of the types registered in the
+// scheme via `SchemeBuilder.Register(&Cluster{}, &ClusterList{})` or
+// `scheme.AddKnownTypes(SchemeGroupVersion, &Cluster{}, &ClusterList{})`
+func (p *packageParser) getRegisteredTypes() map[string]bool {
+	result := make(map[string]bool)
+	for _, f := range p.pkg.Files {
+		ast.Inspect(f, func(node ast.Node) bool {
+			callExpr, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			selector, ok := callExpr.Fun.(*ast.SelectorExpr)
+			if !ok || (selector.Sel.Name != "Register" && selector.Sel.Name != "AddKnownTypes") {
+				return true
+			}
+
+			for _, arg := range callExpr.Args {
+				if unaryExpr, ok := arg.(*ast.UnaryExpr); ok {
+					arg = unaryExpr.X
+				}
+				compositeLit, ok := arg.(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				if ident, ok := compositeLit.Type.(*ast.Ident); ok {
+					result[ident.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return result
+}
+
+// getResource returns the resource metadata of a structure, or nil if the
+// structure is not a root object. Root objects are marked with
+// `+kubebuilder:object:root=true` or `+kubebuilder:resource`, or are
+// registered in the scheme.
+func getResource(kubeStructure KubeStructure, m markers, registered bool) *KubeResource {
+	root, isRoot := m.lookup("kubebuilder:object:root")
+	resourceArgs, hasResource := m.lookup("kubebuilder:resource")
+	if !(isRoot && root != "false") && !hasResource && !registered {
+		return nil
+	}
+
+	// The lists are not resources on their own, so they have no names
+	// and no scope
+	for _, field := range kubeStructure.Fields {
+		if field.Name == "items" && strings.HasSuffix(kubeStructure.Name, "List") {
+			return &KubeResource{
+				Kind: kubeStructure.Name,
+				List: true,
+			}
+		}
+	}
+
+	resource := KubeResource{
+		Kind:     kubeStructure.Name,
+		Scope:    ScopeNamespaced,
+		Plural:   pluralize(strings.ToLower(kubeStructure.Name)),
+		Singular: strings.ToLower(kubeStructure.Name),
+	}
+
+	args := parseMarkerArgs(resourceArgs)
+	if scope, ok := args["scope"]; ok {
+		resource.Scope = scope
+	}
+	if plural, ok := args["path"]; ok {
+		resource.Plural = plural
+	}
+	if singular, ok := args["singular"]; ok {
+		resource.Singular = singular
+	}
+	if shortNames, ok := args["shortname"]; ok {
+		resource.ShortNames = splitMarkerValue(shortNames, ';')
+	}
+	if categories, ok := args["categories"]; ok {
+		resource.Categories = splitMarkerValue(categories, ';')
+	}
+
+	resource.PrintColumns = getPrintColumns(m)
+	resource.Subresources = getSubresources(m)
+
+	return &resource
+}
+
+// getPrintColumns parses the `+kubebuilder:printcolumn` markers
+func getPrintColumns(m markers) []PrintColumn {
+	var result []PrintColumn
+	for _, value := range m.lookupAll("kubebuilder:printcolumn") {
+		args := parseMarkerArgs(value)
+		column := PrintColumn{
+			Name:        args["name"],
+			Type:        args["type"],
+			JSONPath:    args["jsonpath"],
+			Description: args["description"],
+			Format:      args["format"],
+		}
+		if priority, ok := args["priority"]; ok {
+			if parsed, err := strconv.Atoi(priority); err == nil {
+				column.Priority = parsed
+			}
+		}
+		result = append(result, column)
+	}
+	return result
+}
+
+// getSubresources parses the `+kubebuilder:subresource:status` and
+// `+kubebuilder:subresource:scale` markers
+func getSubresources(m markers) Subresources {
+	var result Subresources
+	result.Status = m.has("kubebuilder:subresource:status")
+
+	if value, ok := m.lookup("kubebuilder:subresource:scale"); ok {
+		args := parseMarkerArgs(value)
+		result.Scale = &ScaleSubresource{
+			SpecReplicasPath:   args["specpath"],
+			StatusReplicasPath: args["statuspath"],
+			LabelSelectorPath:  args["selectorpath"],
+		}
+	}
+	return result
+}
+
+// pluralize returns the plural of an English lower case word, with the
+// same simple rules applied by kubectl to guess the resource names
+func pluralize(singular string) string {
+	switch {
+	case strings.HasSuffix(singular, "s"), strings.HasSuffix(singular, "x"),
+		strings.HasSuffix(singular, "z"), strings.HasSuffix(singular, "ch"),
+		strings.HasSuffix(singular, "sh"):
+		return singular + "es"
+	case strings.HasSuffix(singular, "y") && len(singular) > 1 &&
+		!strings.ContainsAny(singular[len(singular)-2:len(singular)-1], "aeiou"):
+		return singular[:len(singular)-1] + "ies"
+	default:
+		return singular + "s"
+	}
+}
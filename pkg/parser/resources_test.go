@@ -0,0 +1,101 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeName string
+		source   string
+		expected *KubeResource
+	}{
+		{
+			name:     "not a root object",
+			typeName: "ClusterSpec",
+			source:   "type ClusterSpec struct{}",
+		},
+		{
+			name:     "root object",
+			typeName: "Cluster",
+			source: `// +kubebuilder:object:root=true
+type Cluster struct{}`,
+			expected: &KubeResource{
+				Kind:     "Cluster",
+				Scope:    ScopeNamespaced,
+				Plural:   "clusters",
+				Singular: "cluster",
+			},
+		},
+		{
+			name:     "resource marker",
+			typeName: "Policy",
+			source: `// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=pol;po,categories=all
+type Policy struct{}`,
+			expected: &KubeResource{
+				Kind:       "Policy",
+				Scope:      ScopeCluster,
+				Plural:     "policies",
+				Singular:   "policy",
+				ShortNames: []string{"pol", "po"},
+				Categories: []string{"all"},
+			},
+		},
+		{
+			name:     "list",
+			typeName: "ClusterList",
+			source: `// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+type ClusterList struct {
+	Items []Cluster ` + "`json:\"items\"`" + `
+}`,
+			expected: &KubeResource{
+				Kind: "ClusterList",
+				List: true,
+			},
+		},
+		{
+			name:     "registered in the scheme",
+			typeName: "Backup",
+			source: `type Backup struct{}
+func init() {
+	SchemeBuilder.Register(&Backup{})
+}`,
+			expected: &KubeResource{
+				Kind:     "Backup",
+				Scope:    ScopeNamespaced,
+				Plural:   "backups",
+				Singular: "backup",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kt, _ := parseSource(t, "package v1\n"+tt.source)
+			resource := findType(t, kt, tt.typeName).Resource
+			if !reflect.DeepEqual(resource, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, resource)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// TypeKind is the kind of a type expression
+type TypeKind string
+
+const (
+	// TypeKindNamed is a type referred by name, i.e. `Pod` or `corev1.Pod`
+	TypeKindNamed = TypeKind("named")
+
+	// TypeKindPointer is a pointer, i.e. `*Pod`
+	TypeKindPointer = TypeKind("pointer")
+
+	// TypeKindSlice is a slice, i.e. `[]Pod`
+	TypeKindSlice = TypeKind("slice")
+
+	// TypeKindArray is a fixed-size array, i.e. `[2]Pod`
+	TypeKindArray = TypeKind("array")
+
+	// TypeKindMap is a map, i.e. `map[string]Pod`
+	TypeKindMap = TypeKind("map")
+
+	// TypeKindStruct is an anonymous structure, which is documented
+	// as a synthesised type
+	TypeKindStruct = TypeKind("struct")
+
+	// TypeKindInterface is an interface, which is not serializable
+	TypeKindInterface = TypeKind("interface")
+
+	// TypeKindFunc is a function, which is not serializable
+	TypeKindFunc = TypeKind("func")
+
+	// TypeKindChan is a channel, which is not serializable
+	TypeKindChan = TypeKind("chan")
+
+	// TypeKindUnsupported is any other type expression, which cannot
+	// be part of a Kubernetes API
+	TypeKindUnsupported = TypeKind("unsupported")
+)
+
+// fieldType builds the type tree of a type expression. Anonymous structures
+// are documented as synthesised types whose name is derived from nameHint.
+func (p *packageParser) fieldType(typ ast.Expr, nameHint string) TypeInfo {
+	switch ft := typ.(type) {
+	case *ast.ParenExpr:
+		return p.fieldType(ft.X, nameHint)
+
+	case *ast.Ident:
+		packagePath := p.scopeOf(ft).packagePath
+		if types.Universe.Lookup(ft.Name) != nil {
+			// Builtin types don't belong to any package
+			packagePath = ""
+		}
+		return TypeInfo{
+			Name:        ft.Name,
+			BaseType:    ft.Name,
+			Constructor: "",
+			Internal:    true,
+			Package:     packagePath,
+			Kind:        TypeKindNamed,
+		}
+
+	case *ast.SelectorExpr:
+		pkg, ok := ft.X.(*ast.Ident)
+		if !ok {
+			return p.unsupportedType(ft, TypeKindNamed)
+		}
+		return TypeInfo{
+			Name:        pkg.Name + "." + ft.Sel.Name,
+			BaseType:    pkg.Name + "." + ft.Sel.Name,
+			Constructor: "",
+			Internal:    false,
+			Package:     p.scopeOf(ft).imports[pkg.Name],
+			Kind:        TypeKindNamed,
+		}
+
+	case *ast.IndexExpr:
+		return p.genericType(ft.X, []ast.Expr{ft.Index}, nameHint)
+
+	case *ast.IndexListExpr:
+		return p.genericType(ft.X, ft.Indices, nameHint)
+
+	case *ast.StarExpr:
+		return wrapType(p.fieldType(ft.X, nameHint), TypeKindPointer, "*")
+
+	case *ast.ArrayType:
+		if ft.Len == nil {
+			return wrapType(p.fieldType(ft.Elt, nameHint), TypeKindSlice, "[]")
+		}
+		return wrapType(p.fieldType(ft.Elt, nameHint), TypeKindArray,
+			fmt.Sprintf("[%v]", types.ExprString(ft.Len)))
+
+	case *ast.MapType:
+		key := p.fieldType(ft.Key, nameHint+"Key")
+		info := wrapType(p.fieldType(ft.Value, nameHint), TypeKindMap,
+			fmt.Sprintf("map[%v]", key.Name))
+		info.Key = &key
+		return info
+
+	case *ast.StructType:
+		return p.anonymousStructType(ft, nameHint)
+
+	case *ast.InterfaceType:
+		return p.unsupportedType(ft, TypeKindInterface)
+
+	case *ast.FuncType:
+		return p.unsupportedType(ft, TypeKindFunc)
+
+	case *ast.ChanType:
+		return p.unsupportedType(ft, TypeKindChan)
+
+	default:
+		return p.unsupportedType(typ, TypeKindUnsupported)
+	}
+}
+
+// wrapType builds the type obtained applying a type constructor,
+// such as `*` or `[]`, to an element type
+func wrapType(elem TypeInfo, kind TypeKind, constructor string) TypeInfo {
+	return TypeInfo{
+		Name:        constructor + elem.Name,
+		BaseType:    elem.BaseType,
+		Constructor: constructor,
+		Internal:    elem.Internal,
+		Package:     elem.Package,
+		Kind:        kind,
+		Elem:        &elem,
+	}
+}
+
+// genericType builds the type of a generic type instantiation,
+// i.e. `List[Pod]`
+func (p *packageParser) genericType(typ ast.Expr, indices []ast.Expr, nameHint string) TypeInfo {
+	info := p.fieldType(typ, nameHint)
+
+	var typeArgNames []string
+	for _, index := range indices {
+		typeArg := p.fieldType(index, nameHint)
+		info.TypeArgs = append(info.TypeArgs, typeArg)
+		typeArgNames = append(typeArgNames, typeArg.Name)
+	}
+	info.Name = fmt.Sprintf("%v[%v]", info.Name, strings.Join(typeArgNames, ", "))
+	return info
+}
+
+// anonymousStructType synthesises a documented type for an anonymous
+// structure, named after the field containing it
+func (p *packageParser) anonymousStructType(structType *ast.StructType, nameHint string) TypeInfo {
+	name, ok := p.anonymousStructNames[structType]
+	if !ok {
+		name = nameHint
+		for idx := 2; p.isDeclaredType(name) || p.isAnonymousStructName(name); idx++ {
+			name = fmt.Sprintf("%v%v", nameHint, idx)
+		}
+		p.anonymousStructNames[structType] = name
+
+		kubeStructure := KubeStructure{
+			Name:      name,
+			Package:   p.packagePath,
+			Position:  p.position(structType.Pos()),
+			Anonymous: true,
+			Hidden:    p.hiding,
+		}
+		kubeStructure.Fields, kubeStructure.Inherits, kubeStructure.HiddenInherits = p.getKubeFields(
+			name, structType, map[string]bool{name: true})
+		p.anonymousStructs = append(p.anonymousStructs, kubeStructure)
+	}
+
+	return TypeInfo{
+		Name:     name,
+		BaseType: name,
+		Internal: true,
+		Package:  p.packagePath,
+		Kind:     TypeKindStruct,
+	}
+}
+
+// isAnonymousStructName returns whether a name has already been used
+// for a synthesised type
+func (p *packageParser) isAnonymousStructName(name string) bool {
+	for _, existing := range p.anonymousStructNames {
+		if existing == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedType reports a type which cannot be part of a Kubernetes
+// API, as it cannot be serialized in JSON, and describes it using its
+// source code
+func (p *packageParser) unsupportedType(typ ast.Expr, kind TypeKind) TypeInfo {
+	name := types.ExprString(typ)
+	p.warnf(typ, "unsupported-type", "%v cannot be serialized to JSON and cannot be documented", name)
+	return TypeInfo{
+		Name:     name,
+		BaseType: name,
+		Internal: true,
+		Kind:     kind,
+	}
+}
+
+// embeddedTypeName returns the name of an embedded type, which is the
+// name of the field in Go, i.e. `Pod` for `*corev1.Pod`
+func embeddedTypeName(typ ast.Expr) string {
+	switch ft := typ.(type) {
+	case *ast.Ident:
+		return ft.Name
+	case *ast.StarExpr:
+		return embeddedTypeName(ft.X)
+	case *ast.SelectorExpr:
+		return ft.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedTypeName(ft.X)
+	case *ast.IndexListExpr:
+		return embeddedTypeName(ft.X)
+	case *ast.ParenExpr:
+		return embeddedTypeName(ft.X)
+	default:
+		return types.ExprString(typ)
+	}
+}
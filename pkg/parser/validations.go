@@ -0,0 +1,196 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Validations are the constraints declared via the kubebuilder
+// validation markers on a field or on a type.
+// Unset numeric constraints are nil.
+type Validations struct {
+	// The minimum value of a number (`+kubebuilder:validation:Minimum`)
+	Minimum *float64
+
+	// The maximum value of a number (`+kubebuilder:validation:Maximum`)
+	Maximum *float64
+
+	// True if the minimum value is excluded (`+kubebuilder:validation:ExclusiveMinimum`)
+	ExclusiveMinimum bool
+
+	// True if the maximum value is excluded (`+kubebuilder:validation:ExclusiveMaximum`)
+	ExclusiveMaximum bool
+
+	// The number must be a multiple of this (`+kubebuilder:validation:MultipleOf`)
+	MultipleOf *float64
+
+	// The minimum length of a string (`+kubebuilder:validation:MinLength`)
+	MinLength *int64
+
+	// The maximum length of a string (`+kubebuilder:validation:MaxLength`)
+	MaxLength *int64
+
+	// The regular expression a string must match (`+kubebuilder:validation:Pattern`)
+	Pattern string
+
+	// The format of a string, i.e. `date-time` (`+kubebuilder:validation:Format`)
+	Format string
+
+	// The allowed values (`+kubebuilder:validation:Enum`)
+	Enum []string
+
+	// The minimum number of items of a list (`+kubebuilder:validation:MinItems`)
+	MinItems *int64
+
+	// The maximum number of items of a list (`+kubebuilder:validation:MaxItems`)
+	MaxItems *int64
+
+	// True if the items of a list must be unique (`+kubebuilder:validation:UniqueItems`)
+	UniqueItems bool
+
+	// The minimum number of properties of a map (`+kubebuilder:validation:MinProperties`)
+	MinProperties *int64
+
+	// The maximum number of properties of a map (`+kubebuilder:validation:MaxProperties`)
+	MaxProperties *int64
+
+	// The OpenAPI type overriding the Go one (`+kubebuilder:validation:Type`)
+	Type string
+
+	// True if the value can be null (`+nullable`)
+	Nullable bool
+
+	// The CEL validation rules (`+kubebuilder:validation:XValidation`)
+	Rules []ValidationRule
+}
+
+// ValidationRule is a CEL expression validating a field or a type
+type ValidationRule struct {
+	// The CEL expression
+	Rule string
+
+	// The message to be shown when the validation fails
+	Message string
+}
+
+// IsEmpty returns true when no constraint is declared
+func (v Validations) IsEmpty() bool {
+	return reflect.DeepEqual(v, Validations{})
+}
+
+const validationMarkerPrefix = "kubebuilder:validation:"
+
+// getValidations builds the validations from the markers of a field or of a
+// type, reporting the invalid ones at the position of the passed node
+func (p *packageParser) getValidations(node ast.Node, m markers) Validations {
+	var v Validations
+
+	v.Minimum = p.floatMarker(node, m, validationMarkerPrefix+"Minimum")
+	v.Maximum = p.floatMarker(node, m, validationMarkerPrefix+"Maximum")
+	v.ExclusiveMinimum = p.boolMarker(node, m, validationMarkerPrefix+"ExclusiveMinimum")
+	v.ExclusiveMaximum = p.boolMarker(node, m, validationMarkerPrefix+"ExclusiveMaximum")
+	v.MultipleOf = p.floatMarker(node, m, validationMarkerPrefix+"MultipleOf")
+	v.MinLength = p.intMarker(node, m, validationMarkerPrefix+"MinLength")
+	v.MaxLength = p.intMarker(node, m, validationMarkerPrefix+"MaxLength")
+	v.MinItems = p.intMarker(node, m, validationMarkerPrefix+"MinItems")
+	v.MaxItems = p.intMarker(node, m, validationMarkerPrefix+"MaxItems")
+	v.UniqueItems = p.boolMarker(node, m, validationMarkerPrefix+"UniqueItems")
+	v.MinProperties = p.intMarker(node, m, validationMarkerPrefix+"MinProperties")
+	v.MaxProperties = p.intMarker(node, m, validationMarkerPrefix+"MaxProperties")
+	v.Nullable = p.boolMarker(node, m, "nullable")
+
+	if value, ok := m.lookup(validationMarkerPrefix + "Pattern"); ok {
+		v.Pattern = unquoteMarkerValue(value)
+	}
+	if value, ok := m.lookup(validationMarkerPrefix + "Format"); ok {
+		v.Format = unquoteMarkerValue(value)
+	}
+	if value, ok := m.lookup(validationMarkerPrefix + "Type"); ok {
+		v.Type = unquoteMarkerValue(value)
+	}
+
+	if value, ok := m.lookup(validationMarkerPrefix + "Enum"); ok {
+		for _, item := range splitMarkerValue(strings.Trim(value, "{}"), ';') {
+			v.Enum = append(v.Enum, unquoteMarkerValue(item))
+		}
+	}
+
+	for _, value := range m.lookupAll(validationMarkerPrefix + "XValidation") {
+		args := parseMarkerArgs(value)
+		v.Rules = append(v.Rules, ValidationRule{
+			Rule:    args["rule"],
+			Message: args["message"],
+		})
+	}
+
+	return v
+}
+
+// floatMarker returns the numeric value of a marker, or nil if the marker
+// is not present or not valid
+func (p *packageParser) floatMarker(node ast.Node, m markers, name string) *float64 {
+	value, ok := m.lookup(name)
+	if !ok {
+		return nil
+	}
+
+	result, err := strconv.ParseFloat(unquoteMarkerValue(value), 64)
+	if err != nil {
+		p.warnf(node, "invalid-marker", "ignoring +%v, %q is not a valid number", name, value)
+		return nil
+	}
+	return &result
+}
+
+// intMarker returns the integer value of a marker, or nil if the marker
+// is not present or not valid
+func (p *packageParser) intMarker(node ast.Node, m markers, name string) *int64 {
+	value, ok := m.lookup(name)
+	if !ok {
+		return nil
+	}
+
+	result, err := strconv.ParseInt(unquoteMarkerValue(value), 10, 64)
+	if err != nil {
+		p.warnf(node, "invalid-marker", "ignoring +%v, %q is not a valid integer", name, value)
+		return nil
+	}
+	return &result
+}
+
+// boolMarker returns the value of a boolean marker. Markers without a
+// value, such as `+nullable`, are considered true
+func (p *packageParser) boolMarker(node ast.Node, m markers, name string) bool {
+	value, ok := m.lookup(name)
+	if !ok {
+		return false
+	}
+	if value == "" {
+		return true
+	}
+
+	result, err := strconv.ParseBool(unquoteMarkerValue(value))
+	if err != nil {
+		p.warnf(node, "invalid-marker", "ignoring +%v, %q is not a valid boolean", name, value)
+		return false
+	}
+	return result
+}
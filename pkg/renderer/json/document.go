@@ -0,0 +1,214 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/versions"
+)
+
+// DocumentAPIVersion is the version of the format of the JSON document, which
+// is described by the JSON Schema published with this software. It must be
+// changed whenever the document changes in a way which is not backward compatible
+const DocumentAPIVersion = "k8s-api-docgen/v1"
+
+// the JSON document
+type document struct {
+	APIVersion string      `json:"apiVersion"`
+	Generator  generator   `json:"generator"`
+	Groups     []groupInfo `json:"groups"`
+	Types      []typeInfo  `json:"types"`
+}
+
+// the software which generated the document
+type generator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// an API group and version, with the identifiers of its types
+type groupInfo struct {
+	Group   string   `json:"group"`
+	Version string   `json:"version"`
+	Types   []string `json:"types"`
+}
+
+// a documented type
+type typeInfo struct {
+	ID                string           `json:"id"`
+	Name              string           `json:"name"`
+	DisplayName       string           `json:"displayName,omitempty"`
+	Package           string           `json:"package,omitempty"`
+	Group             string           `json:"group"`
+	Version           string           `json:"version"`
+	Category          string           `json:"category,omitempty"`
+	Notes             []string         `json:"notes,omitempty"`
+	Description       string           `json:"description"`
+	DescriptionBlocks []docBlock       `json:"descriptionBlocks,omitempty"`
+	Deprecation       *deprecation     `json:"deprecation,omitempty"`
+	Resource          *kubeResource    `json:"resource,omitempty"`
+	Underlying        *kubeTypeRef     `json:"underlying,omitempty"`
+	Enum              []kubeEnumValue  `json:"enum,omitempty"`
+	Inherits          []kubeTypeRef    `json:"inherits,omitempty"`
+	Validations       *kubeValidations `json:"validations,omitempty"`
+	Anonymous         bool             `json:"anonymous,omitempty"`
+	Position          *position        `json:"position,omitempty"`
+	Fields            []fieldInfo      `json:"fields"`
+}
+
+// a field of a documented type
+type fieldInfo struct {
+	Name              string           `json:"name"`
+	DisplayName       string           `json:"displayName,omitempty"`
+	Notes             []string         `json:"notes,omitempty"`
+	Description       string           `json:"description"`
+	DescriptionBlocks []docBlock       `json:"descriptionBlocks,omitempty"`
+	Type              *kubeTypeRef     `json:"type"`
+	Required          bool             `json:"required"`
+	Default           json.RawMessage  `json:"default,omitempty"`
+	Deprecation       *deprecation     `json:"deprecation,omitempty"`
+	Validations       *kubeValidations `json:"validations,omitempty"`
+	Position          *position        `json:"position,omitempty"`
+}
+
+// the deprecation of a type or of a field
+type deprecation struct {
+	Deprecated bool   `json:"deprecated"`
+	Message    string `json:"message,omitempty"`
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+func convertToDeprecation(d parser.Deprecation) *deprecation {
+	if !d.Deprecated {
+		return nil
+	}
+	return &deprecation{
+		Deprecated: true,
+		Message:    d.DeprecationMessage,
+		ReplacedBy: d.ReplacedBy,
+	}
+}
+
+func convertToDocument(kt parser.KubeTypes) document {
+	result := document{
+		APIVersion: DocumentAPIVersion,
+		Generator: generator{
+			Name:    versions.Name,
+			Version: versions.Version,
+		},
+		Groups: []groupInfo{},
+		Types:  make([]typeInfo, 0, len(kt)),
+	}
+
+	// The named types refer to the documented ones by their identifier,
+	// which is their qualified name
+	documentedTypes := make(map[string]bool)
+	for _, kubeStructure := range kt {
+		documentedTypes[kubeStructure.QualifiedName()] = true
+	}
+
+	groups := make(map[parser.GroupVersion]*groupInfo)
+	for _, kubeStructure := range kt {
+		t := typeInfo{
+			ID:                kubeStructure.QualifiedName(),
+			Name:              kubeStructure.Name,
+			DisplayName:       kubeStructure.DisplayName,
+			Package:           kubeStructure.Package,
+			Group:             kubeStructure.GroupVersion.Group,
+			Version:           kubeStructure.GroupVersion.Version,
+			Category:          kubeStructure.Category,
+			Notes:             kubeStructure.Notes,
+			Description:       kubeStructure.Doc,
+			DescriptionBlocks: convertToDocBlocks(kubeStructure.DocTree.Blocks),
+			Deprecation:       convertToDeprecation(kubeStructure.Deprecation),
+			Resource:          convertToKubeResource(kubeStructure.Resource),
+			Validations:       convertToKubeValidations(kubeStructure.Validations),
+			Anonymous:         kubeStructure.Anonymous,
+			Position:          convertToPosition(kubeStructure.Position),
+			Fields:            []fieldInfo{},
+		}
+
+		if kubeStructure.Underlying != nil {
+			t.Underlying = convertToKubeTypeRef(*kubeStructure.Underlying, documentedTypes)
+		}
+		for _, inherited := range kubeStructure.Inherits {
+			t.Inherits = append(t.Inherits, *convertToKubeTypeRef(inherited, documentedTypes))
+		}
+		for _, value := range kubeStructure.Values {
+			t.Enum = append(t.Enum, kubeEnumValue{
+				Name:      value.Name,
+				Value:     value.Value,
+				Doc:       value.Doc,
+				DocBlocks: convertToDocBlocks(value.DocTree.Blocks),
+				Position:  convertToPosition(value.Position),
+			})
+		}
+
+		for _, item := range kubeStructure.Fields {
+			var defaultValue json.RawMessage
+			if item.Default != "" {
+				defaultValue = json.RawMessage(item.Default)
+			}
+
+			t.Fields = append(t.Fields, fieldInfo{
+				Name:              item.Name,
+				DisplayName:       item.DisplayName,
+				Notes:             item.Notes,
+				Description:       item.Doc,
+				DescriptionBlocks: convertToDocBlocks(item.DocTree.Blocks),
+				Type:              convertToKubeTypeRef(item.Type, documentedTypes),
+				Required:          item.Mandatory,
+				Default:           defaultValue,
+				Deprecation:       convertToDeprecation(item.Deprecation),
+				Validations:       convertToKubeValidations(item.Validations),
+				Position:          convertToPosition(item.Position),
+			})
+		}
+		result.Types = append(result.Types, t)
+
+		group, ok := groups[kubeStructure.GroupVersion]
+		if !ok {
+			group = &groupInfo{
+				Group:   kubeStructure.GroupVersion.Group,
+				Version: kubeStructure.GroupVersion.Version,
+			}
+			groups[kubeStructure.GroupVersion] = group
+		}
+		group.Types = append(group.Types, t.ID)
+	}
+
+	for _, group := range groups {
+		result.Groups = append(result.Groups, *group)
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		if result.Groups[i].Group != result.Groups[j].Group {
+			return result.Groups[i].Group < result.Groups[j].Group
+		}
+		return result.Groups[i].Version < result.Groups[j].Version
+	})
+	return result
+}
+
+// ToDocument get a slice of KubeTypes as input and return the versioned JSON
+// document, whose format is described by the published JSON Schema
+func ToDocument(kt parser.KubeTypes) (string, error) {
+	j, err := json.MarshalIndent(convertToDocument(kt), "", "\t")
+	return string(j), err
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+const testPackagePath = "example.com/api/v1"
+
+func TestToDocument(t *testing.T) {
+	podSpec := parser.TypeInfo{
+		Name:     "corev1.PodSpec",
+		BaseType: "corev1.PodSpec",
+		Package:  "k8s.io/api/core/v1",
+		Kind:     parser.TypeKindNamed,
+	}
+	storage := parser.TypeInfo{
+		Name:     "Storage",
+		BaseType: "Storage",
+		Internal: true,
+		Package:  testPackagePath,
+		Kind:     parser.TypeKindNamed,
+	}
+	kt := parser.KubeTypes{
+		{
+			Name:         "Cluster",
+			Package:      testPackagePath,
+			GroupVersion: parser.GroupVersion{Group: "example.com", Version: "v1"},
+			Fields: []parser.KubeField{
+				{
+					Name: "storage",
+					Type: parser.TypeInfo{
+						Name:        "map[string]Storage",
+						BaseType:    "Storage",
+						Constructor: "map[string]",
+						Kind:        parser.TypeKindMap,
+						Elem:        &storage,
+						Key:         &parser.TypeInfo{Name: "string", BaseType: "string", Kind: parser.TypeKindNamed},
+					},
+					Mandatory: true,
+				},
+				{Name: "template", Type: podSpec},
+				{
+					Name:    "instances",
+					Type:    parser.TypeInfo{Name: "int", BaseType: "int", Kind: parser.TypeKindNamed},
+					Default: "3",
+				},
+			},
+		},
+		{
+			Name:         "Storage",
+			Package:      testPackagePath,
+			GroupVersion: parser.GroupVersion{Group: "example.com", Version: "v1"},
+		},
+		{
+			Name:         "Backup",
+			Package:      "example.com/api/v1alpha1",
+			GroupVersion: parser.GroupVersion{Group: "backup.example.com", Version: "v1alpha1"},
+		},
+	}
+
+	result, err := ToDocument(kt)
+	if err != nil {
+		t.Fatalf("cannot write the document: %v", err)
+	}
+	var doc document
+	if err := json.Unmarshal([]byte(result), &doc); err != nil {
+		t.Fatalf("cannot read the document back: %v", err)
+	}
+
+	if doc.APIVersion != DocumentAPIVersion {
+		t.Errorf("expected apiVersion %q, got %q", DocumentAPIVersion, doc.APIVersion)
+	}
+	expectedGroups := []groupInfo{
+		{Group: "backup.example.com", Version: "v1alpha1", Types: []string{"example.com/api/v1alpha1.Backup"}},
+		{Group: "example.com", Version: "v1", Types: []string{testPackagePath + ".Cluster", testPackagePath + ".Storage"}},
+	}
+	if !reflect.DeepEqual(doc.Groups, expectedGroups) {
+		t.Errorf("expected groups %+v, got %+v", expectedGroups, doc.Groups)
+	}
+
+	fields := doc.Types[0].Fields
+	storageType := fields[0].Type
+	if storageType.Kind != "map" || storageType.Key.Name != "string" || storageType.Elem.Ref != testPackagePath+".Storage" {
+		t.Errorf("expected a map of the documented Storage type, got %+v", storageType)
+	}
+	if templateType := fields[1].Type; templateType.Ref != "" || templateType.Package != "k8s.io/api/core/v1" {
+		t.Errorf("expected the external type not to refer to a documented type, got %+v", templateType)
+	}
+	if string(fields[2].Default) != "3" || fields[2].Required {
+		t.Errorf("expected an optional field with a default value, got %+v", fields[2])
+	}
+}
@@ -19,7 +19,10 @@ package json
 
 import (
 	"encoding/json"
+	"path/filepath"
 
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/doctree"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/model"
 	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
 )
 
@@ -30,6 +33,59 @@ type kubeType struct {
 	Items []kubeItem `json:"items"`
 }
 
+// place in the source code where an element is declared
+type position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// metadata of root objects
+type kubeResource struct {
+	Kind       string   `json:"kind"`
+	List       bool     `json:"list,omitempty"`
+	Scope      string   `json:"scope,omitempty"`
+	Plural     string   `json:"plural,omitempty"`
+	Singular   string   `json:"singular,omitempty"`
+	ShortNames []string `json:"shortNames,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+
+	PrintColumns []printColumn `json:"printColumns,omitempty"`
+	Subresources *subresources `json:"subresources,omitempty"`
+}
+
+// additional columns shown by kubectl
+type printColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	JSONPath    string `json:"jsonPath"`
+	Description string `json:"description,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Priority    int    `json:"priority,omitempty"`
+}
+
+// subresources served by the API server
+type subresources struct {
+	Status bool   `json:"status,omitempty"`
+	Scale  *scale `json:"scale,omitempty"`
+}
+
+// scale subresource
+type scale struct {
+	SpecReplicasPath   string `json:"specReplicasPath"`
+	StatusReplicasPath string `json:"statusReplicasPath"`
+	LabelSelectorPath  string `json:"labelSelectorPath,omitempty"`
+}
+
+// values of named types
+type kubeEnumValue struct {
+	Name      string     `json:"name"`
+	Value     string     `json:"value"`
+	Doc       string     `json:"description"`
+	DocBlocks []docBlock `json:"descriptionBlocks,omitempty"`
+	Position  *position  `json:"position,omitempty"`
+}
+
 // k8s items
 type kubeItem struct {
 	Name      string `json:"field"`
@@ -38,6 +94,206 @@ type kubeItem struct {
 	Mandatory bool   `json:"required"`
 }
 
+// a block of the documentation, parsed with the Go doc comment syntax
+type docBlock struct {
+	Kind    string        `json:"kind"`
+	Text    []docInline   `json:"text,omitempty"`
+	Items   []docListItem `json:"items,omitempty"`
+	Ordered bool          `json:"ordered,omitempty"`
+	Code    string        `json:"code,omitempty"`
+}
+
+// an item of a list
+type docListItem struct {
+	Number string     `json:"number,omitempty"`
+	Blocks []docBlock `json:"blocks"`
+}
+
+// a span of text or a link
+type docInline struct {
+	Kind       string `json:"kind"`
+	Text       string `json:"text"`
+	URL        string `json:"url,omitempty"`
+	ImportPath string `json:"importPath,omitempty"`
+	Recv       string `json:"recv,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// the structure of a type, one level for each type constructor
+type kubeTypeRef struct {
+	Kind     string        `json:"kind"`
+	Name     string        `json:"name"`
+	Package  string        `json:"package,omitempty"`
+	Ref      string        `json:"ref,omitempty"`
+	Elem     *kubeTypeRef  `json:"elem,omitempty"`
+	Key      *kubeTypeRef  `json:"key,omitempty"`
+	TypeArgs []kubeTypeRef `json:"typeArgs,omitempty"`
+}
+
+// constraints declared via validation markers
+type kubeValidations struct {
+	Minimum          *float64         `json:"minimum,omitempty"`
+	Maximum          *float64         `json:"maximum,omitempty"`
+	ExclusiveMinimum bool             `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool             `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64         `json:"multipleOf,omitempty"`
+	MinLength        *int64           `json:"minLength,omitempty"`
+	MaxLength        *int64           `json:"maxLength,omitempty"`
+	Pattern          string           `json:"pattern,omitempty"`
+	Format           string           `json:"format,omitempty"`
+	Enum             []string         `json:"enum,omitempty"`
+	MinItems         *int64           `json:"minItems,omitempty"`
+	MaxItems         *int64           `json:"maxItems,omitempty"`
+	UniqueItems      bool             `json:"uniqueItems,omitempty"`
+	MinProperties    *int64           `json:"minProperties,omitempty"`
+	MaxProperties    *int64           `json:"maxProperties,omitempty"`
+	Type             string           `json:"type,omitempty"`
+	Nullable         bool             `json:"nullable,omitempty"`
+	Rules            []validationRule `json:"rules,omitempty"`
+}
+
+// CEL validation rules
+type validationRule struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message,omitempty"`
+}
+
+func convertToPosition(pos parser.Position) *position {
+	if !pos.IsValid() {
+		return nil
+	}
+	return &position{
+		File:   filepath.ToSlash(pos.Filename),
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+}
+
+// convertToKubeTypeRef converts the structure of a type. The named types
+// whose qualified name is in documentedTypes refer to their definition
+func convertToKubeTypeRef(info parser.TypeInfo, documentedTypes map[string]bool) *kubeTypeRef {
+	result := kubeTypeRef{
+		Kind: string(info.Kind),
+		Name: info.Name,
+	}
+	if info.Elem != nil {
+		result.Elem = convertToKubeTypeRef(*info.Elem, documentedTypes)
+	} else {
+		result.Package = info.Package
+		if documentedTypes[info.QualifiedName()] {
+			result.Ref = info.QualifiedName()
+		}
+	}
+	if info.Key != nil {
+		result.Key = convertToKubeTypeRef(*info.Key, documentedTypes)
+	}
+	for _, typeArg := range info.TypeArgs {
+		result.TypeArgs = append(result.TypeArgs, *convertToKubeTypeRef(typeArg, documentedTypes))
+	}
+	return &result
+}
+
+func convertToKubeResource(resource *parser.KubeResource) *kubeResource {
+	if resource == nil {
+		return nil
+	}
+
+	result := kubeResource{
+		Kind:       resource.Kind,
+		List:       resource.List,
+		Scope:      resource.Scope,
+		Plural:     resource.Plural,
+		Singular:   resource.Singular,
+		ShortNames: resource.ShortNames,
+		Categories: resource.Categories,
+	}
+	for _, column := range resource.PrintColumns {
+		result.PrintColumns = append(result.PrintColumns, printColumn{
+			Name:        column.Name,
+			Type:        column.Type,
+			JSONPath:    column.JSONPath,
+			Description: column.Description,
+			Format:      column.Format,
+			Priority:    column.Priority,
+		})
+	}
+	if resource.Subresources.Status || resource.Subresources.Scale != nil {
+		result.Subresources = &subresources{
+			Status: resource.Subresources.Status,
+		}
+		if resourceScale := resource.Subresources.Scale; resourceScale != nil {
+			result.Subresources.Scale = &scale{
+				SpecReplicasPath:   resourceScale.SpecReplicasPath,
+				StatusReplicasPath: resourceScale.StatusReplicasPath,
+				LabelSelectorPath:  resourceScale.LabelSelectorPath,
+			}
+		}
+	}
+	return &result
+}
+
+func convertToDocBlocks(blocks []doctree.Block) []docBlock {
+	var result []docBlock
+	for _, block := range blocks {
+		converted := docBlock{
+			Kind:    string(block.Kind),
+			Ordered: block.Ordered,
+			Code:    block.Code,
+		}
+		for _, span := range block.Text {
+			converted.Text = append(converted.Text, docInline{
+				Kind:       string(span.Kind),
+				Text:       span.Text,
+				URL:        span.URL,
+				ImportPath: span.ImportPath,
+				Recv:       span.Recv,
+				Name:       span.Name,
+			})
+		}
+		for _, item := range block.Items {
+			converted.Items = append(converted.Items, docListItem{
+				Number: item.Number,
+				Blocks: convertToDocBlocks(item.Blocks),
+			})
+		}
+		result = append(result, converted)
+	}
+	return result
+}
+
+func convertToKubeValidations(v parser.Validations) *kubeValidations {
+	if v.IsEmpty() {
+		return nil
+	}
+
+	result := kubeValidations{
+		Minimum:          v.Minimum,
+		Maximum:          v.Maximum,
+		ExclusiveMinimum: v.ExclusiveMinimum,
+		ExclusiveMaximum: v.ExclusiveMaximum,
+		MultipleOf:       v.MultipleOf,
+		MinLength:        v.MinLength,
+		MaxLength:        v.MaxLength,
+		Pattern:          v.Pattern,
+		Format:           v.Format,
+		Enum:             v.Enum,
+		MinItems:         v.MinItems,
+		MaxItems:         v.MaxItems,
+		UniqueItems:      v.UniqueItems,
+		MinProperties:    v.MinProperties,
+		MaxProperties:    v.MaxProperties,
+		Type:             v.Type,
+		Nullable:         v.Nullable,
+	}
+	for _, rule := range v.Rules {
+		result.Rules = append(result.Rules, validationRule{
+			Rule:    rule.Rule,
+			Message: rule.Message,
+		})
+	}
+	return &result
+}
+
 func convertToKubeTypes(kt parser.KubeTypes) []kubeType {
 	kubeDocs := make([]kubeType, len(kt))
 	for idx, kubeStructure := range kt {
@@ -62,9 +318,18 @@ func convertToKubeTypes(kt parser.KubeTypes) []kubeType {
 
 // ToJSON get a slice of KubeTypes as input and return the JSON documentation.
 // JSON fields are the ones defined in kubeTypes (and kubeItem) definition.
+// This is the format written by the previous versions, which has been
+// superseded by the one written by ToDocument.
 func ToJSON(kt parser.KubeTypes) (string, error) {
 	kubeDocs := convertToKubeTypes(kt)
 
 	j, err := json.MarshalIndent(kubeDocs, "", "\t")
 	return string(j), err
 }
+
+// ToModel get a slice of KubeTypes as input and return their complete model,
+// which can be read back with the model package to render it later
+func ToModel(kt parser.KubeTypes) (string, error) {
+	j, err := json.MarshalIndent(model.FromKubeTypes(kt), "", "\t")
+	return string(j), err
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+func TestToJSON(t *testing.T) {
+	kt := parser.KubeTypes{
+		{
+			Name: "Cluster",
+			Doc:  "Cluster is a cluster",
+			Fields: []parser.KubeField{
+				{
+					Name:      "instances",
+					Doc:       "The number of instances",
+					Type:      parser.TypeInfo{Name: "*int", Kind: parser.TypeKindPointer},
+					Mandatory: true,
+				},
+			},
+		},
+	}
+
+	result, err := ToJSON(kt)
+	if err != nil {
+		t.Fatalf("cannot write the types: %v", err)
+	}
+	expected := `[
+	{
+		"name": "Cluster",
+		"description": "Cluster is a cluster",
+		"items": [
+			{
+				"field": "instances",
+				"description": "The number of instances",
+				"schema": "*int",
+				"required": true
+			}
+		]
+	}
+]`
+	if result != expected {
+		t.Errorf("expected the format of the previous versions:\n%v\ngot:\n%v", expected, result)
+	}
+}
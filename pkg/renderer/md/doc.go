@@ -0,0 +1,278 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package md
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/doctree"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// seeRegexp matches the references written in plain text, i.e.
+// "see StorageConfiguration" or "see Cluster.spec"
+var seeRegexp = regexp.MustCompile(
+	`\b[Ss]ee\s+(?:the\s+)?([A-Z][A-Za-z0-9_]*)(?:\.([A-Za-z_][A-Za-z0-9_]*))?`)
+
+// docLinker resolves the cross references written in the documentation
+// of an element to the documented types or to the external ones
+type docLinker struct {
+	// The anchors of the documented types, indexed by qualified name
+	documentedTypes map[string]string
+
+	// The documented types, indexed by qualified name
+	structures map[string]parser.KubeStructure
+
+	// The import path of the package of the documented element, used
+	// for the references which don't specify one
+	packagePath string
+
+	// Where the documented element is declared
+	position parser.Position
+
+	// The unresolved references already reported, as the documentation
+	// of the inlined fields is rendered more than once
+	reported map[string]bool
+
+	report *diagnostics.Diagnostics
+}
+
+// forElement returns the linker of the documentation of an element
+func (l docLinker) forElement(packagePath string, position parser.Position) docLinker {
+	l.packagePath = packagePath
+	l.position = position
+	return l
+}
+
+// resolve returns the URL of a doc link, i.e. `[StorageConfiguration]` or
+// `[Cluster.spec]`. The unresolved links are reported, unless quiet is set
+func (l docLinker) resolve(span doctree.Inline, quiet bool) (string, bool) {
+	typeName, fieldName := span.Name, ""
+	if span.Recv != "" {
+		typeName, fieldName = span.Recv, span.Name
+	}
+	importPath := span.ImportPath
+	if importPath == "" {
+		importPath = l.packagePath
+	}
+	qualifiedName := typeName
+	if importPath != "" {
+		qualifiedName = importPath + "." + typeName
+	}
+
+	if anchorID, documented := l.documentedTypes[qualifiedName]; documented {
+		if fieldName != "" && !hasField(l.structures[qualifiedName], fieldName) {
+			if !quiet {
+				l.warnf(span, "reference to unknown field %v of %v", fieldName, typeName)
+			}
+			return "", false
+		}
+		return "#" + anchorID, true
+	}
+
+	// External types are looked up as done for the field types, by qualified
+	// name and then by the name written in the documentation
+	section, ok := conf.Sections[qualifiedName]
+	if !ok && fieldName == "" {
+		section, ok = conf.Sections[span.Text]
+	}
+	if ok {
+		return fmt.Sprintf("%v/%v/%v", conf.K8sURL, conf.Version, section), true
+	}
+
+	if !quiet {
+		l.warnf(span, "unresolved reference to %v", span.Text)
+	}
+	return "", false
+}
+
+func (l docLinker) warnf(span doctree.Inline, format string, args ...interface{}) {
+	key := fmt.Sprintf("%v|%v|%v", l.position.String(), l.packagePath, span.Text)
+	if l.reported[key] {
+		return
+	}
+	l.reported[key] = true
+	l.report.Warnf(l.position.Filename, l.position.Line, l.position.Column,
+		"unresolved-reference", format, args...)
+}
+
+// hasField returns whether a structure has a field, which can be referred
+// by its JSON name or by its Go name
+func hasField(kubeStructure parser.KubeStructure, name string) bool {
+	for _, field := range kubeStructure.Fields {
+		if strings.EqualFold(field.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderDoc renders a document as Markdown blocks, to be used where a
+// paragraph can be written. The normalized documentation is used when
+// the document has not been parsed
+func (l docLinker) renderDoc(document doctree.Document, doc string) string {
+	if document.IsEmpty() {
+		return doc
+	}
+	return l.renderBlocks(document.Blocks)
+}
+
+func (l docLinker) renderBlocks(blocks []doctree.Block) string {
+	var result []string
+	for _, block := range blocks {
+		switch block.Kind {
+		case doctree.BlockParagraph:
+			result = append(result, l.renderText(block.Text, false))
+
+		case doctree.BlockHeading:
+			// The types are level 2 headings
+			result = append(result, "### "+l.renderText(block.Text, false))
+
+		case doctree.BlockCode:
+			result = append(result, "```\n"+block.Code+"\n```")
+
+		case doctree.BlockList:
+			var items []string
+			for idx, item := range block.Items {
+				marker := "- "
+				if block.Ordered {
+					marker = fmt.Sprintf("%v. ", listItemNumber(item, idx))
+				}
+				// The content following the first line is indented
+				// to be part of the item
+				indentation := strings.Repeat(" ", len(marker))
+				content := strings.ReplaceAll(l.renderBlocks(item.Blocks), "\n", "\n"+indentation)
+				items = append(items, marker+strings.ReplaceAll(content, "\n"+indentation+"\n", "\n\n"))
+			}
+			result = append(result, strings.Join(items, "\n"))
+		}
+	}
+	return strings.Join(result, "\n\n")
+}
+
+// renderDocCell renders a document in a single line, to be used in
+// a table cell. Lists and code blocks are written in HTML
+func (l docLinker) renderDocCell(document doctree.Document, doc string) string {
+	if document.IsEmpty() {
+		return doc
+	}
+	return l.renderCellBlocks(document.Blocks, "<br><br>")
+}
+
+func (l docLinker) renderCellBlocks(blocks []doctree.Block, separator string) string {
+	var result []string
+	for _, block := range blocks {
+		switch block.Kind {
+		case doctree.BlockParagraph:
+			result = append(result, l.renderText(block.Text, true))
+
+		case doctree.BlockHeading:
+			result = append(result, "**"+l.renderText(block.Text, true)+"**")
+
+		case doctree.BlockCode:
+			code := strings.ReplaceAll(html.EscapeString(block.Code), "|", "&#124;")
+			result = append(result, "<pre>"+strings.ReplaceAll(code, "\n", "<br>")+"</pre>")
+
+		case doctree.BlockList:
+			tag := "ul"
+			if block.Ordered {
+				tag = "ol"
+			}
+			var items strings.Builder
+			for _, item := range block.Items {
+				items.WriteString("<li>" + l.renderCellBlocks(item.Blocks, "<br>") + "</li>")
+			}
+			result = append(result, fmt.Sprintf("<%v>%v</%v>", tag, items.String(), tag))
+		}
+	}
+	return strings.Join(result, separator)
+}
+
+// renderText renders a span of text in a single line, escaping the
+// characters which would break a table if requested
+func (l docLinker) renderText(text []doctree.Inline, inTable bool) string {
+	escape := func(s string) string {
+		s = strings.ReplaceAll(s, "\n", " ")
+		if inTable {
+			s = strings.ReplaceAll(s, "|", "\\|")
+		}
+		return s
+	}
+
+	var result strings.Builder
+	for _, span := range text {
+		switch span.Kind {
+		case doctree.InlineLink:
+			if span.Text == span.URL {
+				fmt.Fprintf(&result, "<%v>", span.URL)
+			} else {
+				fmt.Fprintf(&result, "[%v](%v)", escape(span.Text), span.URL)
+			}
+
+		case doctree.InlineDocLink:
+			if url, ok := l.resolve(span, false); ok {
+				fmt.Fprintf(&result, "[%v](%v)", escape(span.Text), url)
+			} else {
+				result.WriteString(escape(span.Text))
+			}
+
+		default:
+			result.WriteString(l.linkReferences(escape(span.Text)))
+		}
+	}
+	return result.String()
+}
+
+// linkReferences links the references written in plain text, such as
+// "see StorageConfiguration", when they can be resolved
+func (l docLinker) linkReferences(text string) string {
+	return seeRegexp.ReplaceAllStringFunc(text, func(match string) string {
+		submatches := seeRegexp.FindStringSubmatch(match)
+		span := doctree.Inline{Kind: doctree.InlineDocLink, Name: submatches[1]}
+		reference := submatches[1]
+		if submatches[2] != "" {
+			span.Recv, span.Name = submatches[1], submatches[2]
+			reference += "." + submatches[2]
+		}
+		span.Text = reference
+
+		url, ok := l.resolve(span, true)
+		if !ok && span.Recv != "" {
+			// "see Cluster.Something" may be the end of a sentence
+			span = doctree.Inline{Kind: doctree.InlineDocLink, Name: submatches[1], Text: submatches[1]}
+			reference = submatches[1]
+			url, ok = l.resolve(span, true)
+		}
+		if !ok {
+			return match
+		}
+		idx := strings.LastIndex(match, reference)
+		return match[:idx] + fmt.Sprintf("[%v](%v)", reference, url) + match[idx+len(reference):]
+	})
+}
+
+// listItemNumber returns the number of an item of a numbered list
+func listItemNumber(item doctree.ListItem, idx int) string {
+	if item.Number != "" {
+		return item.Number
+	}
+	return fmt.Sprint(idx + 1)
+}
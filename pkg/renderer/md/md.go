@@ -21,42 +21,183 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"unicode"
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
 	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
 )
 
 // k8s types for generation of docs
 type kubeType struct {
-	Name                      string
-	NameWithAnchor            string
-	Anchor                    string
-	Doc                       string
-	Items                     []kubeItem
-	TableFieldName            string
-	TableFieldNameDashSize    string
-	TableFieldDoc             string
-	TableFieldDocDashSize     string
-	TableFieldRawType         string
-	TableFieldRawTypeDashSize string
-	TableFieldMandatory       string
-
-	maxSizeOfName    int
-	maxSizeOfDoc     int
-	maxSizeOfRawType int
+	Name                       string
+	NameWithAnchor             string
+	Anchor                     string
+	AnchorID                   string
+	Group                      string
+	Version                    string
+	APIVersion                 string
+	Resource                   *parser.KubeResource
+	Deprecated                 bool
+	DeprecationMessage         string
+	PrintColumns               []kubePrintColumn
+	Subresources               string
+	Doc                        string
+	SourceLink                 string
+	Category                   string
+	Notes                      []string
+	Validations                parser.Validations
+	Constraints                string
+	Inherits                   []string
+	Underlying                 string
+	Values                     []kubeEnumValue
+	Items                      []kubeItem
+	TableFieldName             string
+	TableFieldNameDashSize     string
+	TableFieldDoc              string
+	TableFieldDocDashSize      string
+	TableFieldRawType          string
+	TableFieldRawTypeDashSize  string
+	TableFieldMandatory        string
+	TableFieldDefault          string
+	TableFieldDefaultDashSize  string
+	HasDefaults                bool
+	TableFieldValue            string
+	TableFieldValueDashSize    string
+	TableFieldValueDoc         string
+	TableFieldValueDocDashSize string
+
+	maxSizeOfName     int
+	maxSizeOfDoc      int
+	maxSizeOfRawType  int
+	maxSizeOfDefault  int
+	maxSizeOfValue    int
+	maxSizeOfValueDoc int
+}
+
+// values of named types
+type kubeEnumValue struct {
+	Name       string
+	Value      string
+	Doc        string
+	SourceLink string
+}
+
+// kubeTypes is the list of types passed to the template
+type kubeTypes []kubeType
+
+// API group and version, with the types belonging to it
+type kubeGroupVersion struct {
+	Group      string
+	Version    string
+	APIVersion string
+	Anchor     string
+	AnchorID   string
+	Types      []kubeType
+}
+
+// GroupVersions returns the types grouped by API group and version,
+// sorted by group and version
+func (kt kubeTypes) GroupVersions() []kubeGroupVersion {
+	var result []kubeGroupVersion
+	index := make(map[string]int)
+	for _, k := range kt {
+		idx, ok := index[k.APIVersion]
+		if !ok {
+			idx = len(result)
+			index[k.APIVersion] = idx
+			anchorID := anchorName(k.APIVersion)
+			result = append(result, kubeGroupVersion{
+				Group:      k.Group,
+				Version:    k.Version,
+				APIVersion: k.APIVersion,
+				Anchor:     applyAnchor(anchorID),
+				AnchorID:   anchorID,
+			})
+		}
+		result[idx].Types = append(result[idx].Types, k)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Group != result[j].Group {
+			return result[i].Group < result[j].Group
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
+// HasMultipleGroupVersions returns true if the types belong to more than
+// one API group and version
+func (kt kubeTypes) HasMultipleGroupVersions() bool {
+	return len(kt.GroupVersions()) > 1
+}
+
+// additional column shown by kubectl
+type kubePrintColumn struct {
+	Name        string
+	Type        string
+	JSONPath    string
+	Description string
+	Priority    int
+}
+
+// root object listed in the resources index
+type kubeResource struct {
+	APIVersion string
+	Kind       string
+	AnchorID   string
+	Scope      string
+	Plural     string
+	ShortNames string
+}
+
+// Resources returns the root objects, excluding the lists, to be
+// shown in the resources index
+func (kt kubeTypes) Resources() []kubeResource {
+	var result []kubeResource
+	for _, gv := range kt.GroupVersions() {
+		for _, k := range gv.Types {
+			if k.Resource == nil || k.Resource.List {
+				continue
+			}
+			var shortNames []string
+			for _, shortName := range k.Resource.ShortNames {
+				shortNames = append(shortNames, fmt.Sprintf("`%v`", shortName))
+			}
+			result = append(result, kubeResource{
+				APIVersion: k.APIVersion,
+				Kind:       k.Resource.Kind,
+				AnchorID:   k.AnchorID,
+				Scope:      k.Resource.Scope,
+				Plural:     k.Resource.Plural,
+				ShortNames: strings.Join(shortNames, ", "),
+			})
+		}
+	}
+	return result
 }
 
 // k8s items
 type kubeItem struct {
-	Name      string
-	Doc       string
-	Type      string
-	RawType   string
-	Mandatory bool
+	Name        string
+	Doc         string
+	Type        string
+	RawType     string
+	Mandatory   bool
+	Deprecated  bool
+	Deprecation string
+	Default     string
+	Validations parser.Validations
+	Constraints string
+	SourceLink  string
+	Notes       []string
 }
 
 // Markdown configuration to be provided via YAML file
@@ -65,16 +206,23 @@ type mdConfiguration struct {
 	TableFieldDoc       string            `yaml:"doc,omitempty"`
 	TableFieldRawType   string            `yaml:"type,omitempty"`
 	TableFieldMandatory string            `yaml:"mandatory,omitempty"`
+	TableFieldDefault   string            `yaml:"default,omitempty"`
+	TableFieldValue     string            `yaml:"value,omitempty"`
 	K8sURL              string            `yaml:"k8s_url,omitempty"`
 	Version             string            `yaml:"version,omitempty"`
 	Sections            map[string]string `yaml:"sections,omitempty"`
+	SourceLink          string            `yaml:"source_link,omitempty"`
+	SourceRef           string            `yaml:"source_ref,omitempty"`
 }
 
 var conf mdConfiguration
 
 // ToMd gets a slice of KubeTypes and the path to YAML file of the Markdown configuration.
-// It returns the Markdown documentation.
-func ToMd(kt parser.KubeTypes, mdConfiguration string, mdTemplate string) (string, error) {
+// It returns the Markdown documentation. The references written in the
+// documentation which cannot be resolved are added to the report.
+func ToMd(
+	kt parser.KubeTypes, mdConfiguration string, mdTemplate string, report *diagnostics.Diagnostics,
+) (string, error) {
 	if mdConfiguration != "" {
 		configurationFile, err := os.ReadFile(mdConfiguration) // #nosec
 		if err != nil {
@@ -90,8 +238,17 @@ func ToMd(kt parser.KubeTypes, mdConfiguration string, mdTemplate string) (strin
 		conf.TableFieldRawType = "Type"
 		conf.TableFieldMandatory = "Mandatory"
 	}
+	if conf.TableFieldDefault == "" {
+		conf.TableFieldDefault = "Default"
+	}
+	if conf.TableFieldValue == "" {
+		conf.TableFieldValue = "Value"
+	}
+	if conf.SourceRef == "" {
+		conf.SourceRef = "main"
+	}
 
-	kubeDocs := convertToKubeTypes(kt)
+	kubeDocs := convertToKubeTypes(kt, report)
 	format(kubeDocs)
 
 	templateFile, err := os.ReadFile(mdTemplate) // #nosec
@@ -105,19 +262,55 @@ func ToMd(kt parser.KubeTypes, mdConfiguration string, mdTemplate string) (strin
 	return md, err
 }
 
-func convertToKubeTypes(kt parser.KubeTypes) []kubeType {
-	internalTypes := make(map[string]bool)
+func convertToKubeTypes(kt parser.KubeTypes, report *diagnostics.Diagnostics) kubeTypes {
+	// When documenting more than one API version the same type name can
+	// be used in different versions, so the anchors must include the version
+	groupVersions := make(map[parser.GroupVersion]bool)
 	for _, kubeStructure := range kt {
-		internalTypes[kubeStructure.Name] = true
+		groupVersions[kubeStructure.GroupVersion] = true
 	}
+	qualifyAnchors := len(groupVersions) > 1
 
-	kubeDocs := make([]kubeType, len(kt))
+	// The anchors of the documented types, indexed by qualified name
+	documentedTypes := make(map[string]string)
+	structures := make(map[string]parser.KubeStructure)
+	for _, kubeStructure := range kt {
+		documentedTypes[kubeStructure.QualifiedName()] = typeAnchorID(kubeStructure, qualifyAnchors)
+		structures[kubeStructure.QualifiedName()] = kubeStructure
+	}
+	linker := docLinker{
+		documentedTypes: documentedTypes,
+		structures:      structures,
+		reported:        make(map[string]bool),
+		report:          report,
+	}
+
+	kubeDocs := make(kubeTypes, len(kt))
 	for idx, kubeStructure := range kt {
+		anchorID := typeAnchorID(kubeStructure, qualifyAnchors)
+		name := kubeStructure.Name
+		if kubeStructure.DisplayName != "" {
+			name = kubeStructure.DisplayName
+		}
+		typeDoc := linker.forElement(kubeStructure.Package, kubeStructure.Position).
+			renderDoc(kubeStructure.DocTree, kubeStructure.Doc)
 		k := kubeType{
-			Name:                      kubeStructure.Name,
-			Anchor:                    applyAnchor(kubeStructure.Name),
-			NameWithAnchor:            applyNameWithAnchor(kubeStructure.Name),
-			Doc:                       kubeStructure.Doc,
+			Name:                      name,
+			Anchor:                    applyAnchor(anchorID),
+			AnchorID:                  anchorID,
+			NameWithAnchor:            applyNameWithAnchor(anchorID, name),
+			Group:                     kubeStructure.GroupVersion.Group,
+			Version:                   kubeStructure.GroupVersion.Version,
+			APIVersion:                kubeStructure.GroupVersion.String(),
+			Resource:                  kubeStructure.Resource,
+			Deprecated:                kubeStructure.Deprecated,
+			DeprecationMessage:        kubeStructure.DeprecationMessage,
+			Doc:                       typeDoc,
+			SourceLink:                sourceLink(kubeStructure.Position),
+			Category:                  kubeStructure.Category,
+			Notes:                     kubeStructure.Notes,
+			Validations:               kubeStructure.Validations,
+			Constraints:               formatValidations(kubeStructure.Validations, false),
 			Items:                     nil,
 			TableFieldName:            "",
 			TableFieldNameDashSize:    "",
@@ -127,20 +320,77 @@ func convertToKubeTypes(kt parser.KubeTypes) []kubeType {
 			TableFieldRawTypeDashSize: "",
 		}
 
+		if resource := kubeStructure.Resource; resource != nil {
+			for _, column := range resource.PrintColumns {
+				k.PrintColumns = append(k.PrintColumns, kubePrintColumn{
+					Name:        column.Name,
+					Type:        column.Type,
+					JSONPath:    fmt.Sprintf("`%v`", escapeCell(column.JSONPath)),
+					Description: escapeCell(column.Description),
+					Priority:    column.Priority,
+				})
+			}
+			k.Subresources = formatSubresources(resource.Subresources)
+		}
+
+		for _, inherited := range kubeStructure.Inherits {
+			k.Inherits = append(k.Inherits, wrapInLink(inherited, documentedTypes))
+		}
+
+		if kubeStructure.Underlying != nil {
+			k.Underlying = wrapInLink(*kubeStructure.Underlying, documentedTypes)
+		}
+		for _, value := range kubeStructure.Values {
+			quotedValue := fmt.Sprintf("`%v`", value.Value)
+			valueDoc := linker.forElement(kubeStructure.Package, value.Position).
+				renderDocCell(value.DocTree, value.Doc)
+			k.Values = append(k.Values, kubeEnumValue{
+				Name:       value.Name,
+				Value:      quotedValue,
+				Doc:        valueDoc,
+				SourceLink: sourceLink(value.Position),
+			})
+			k.maxSizeOfValue = max(k.maxSizeOfValue, len(quotedValue))
+			k.maxSizeOfValueDoc = max(k.maxSizeOfValueDoc, len(valueDoc))
+		}
+
 		var items []kubeItem
 		for _, item := range kubeStructure.Fields {
-			typeField := wrapInLink(item.Type, internalTypes)
+			typeField := wrapInLink(item.Type, documentedTypes)
+			defaultValue := ""
+			if item.Default != "" {
+				defaultValue = fmt.Sprintf("`%v`", escapeCell(item.Default))
+				k.HasDefaults = true
+			}
+			name := item.Name
+			if item.DisplayName != "" {
+				name = item.DisplayName
+			}
+			itemDoc := linker.forElement(kubeStructure.Package, item.Position).
+				renderDocCell(item.DocTree, item.Doc)
+			var notes []string
+			for _, note := range item.Notes {
+				notes = append(notes, escapeCell(note))
+			}
 			items = append(items, kubeItem{
-				Name:      item.Name,
-				Doc:       item.Doc,
-				Type:      item.Type.Name,
-				RawType:   typeField,
-				Mandatory: item.Mandatory,
+				Name:        name,
+				Doc:         itemDoc,
+				Type:        item.Type.Name,
+				RawType:     typeField,
+				Mandatory:   item.Mandatory,
+				Deprecated:  item.Deprecated,
+				Deprecation: formatDeprecation(item.Deprecation),
+				Default:     defaultValue,
+				Validations: item.Validations,
+				Constraints: formatValidations(item.Validations, true),
+				SourceLink:  sourceLink(item.Position),
+				Notes:       notes,
 			})
 
-			k.maxSizeOfName = max(k.maxSizeOfName, len(item.Name))
-			k.maxSizeOfDoc = max(k.maxSizeOfDoc, len(item.Doc))
+			k.maxSizeOfName = max(k.maxSizeOfName, len(name))
+			k.maxSizeOfDoc = max(k.maxSizeOfDoc, len(itemDoc))
 			k.maxSizeOfRawType = max(k.maxSizeOfRawType, len(typeField))
+			k.maxSizeOfDefault = max(k.maxSizeOfDefault, len(defaultValue))
 		}
 		k.Items = items
 		kubeDocs[idx] = k
@@ -149,7 +399,7 @@ func convertToKubeTypes(kt parser.KubeTypes) []kubeType {
 }
 
 // format applies proper formats to tables and documentation
-func format(kubeDocs []kubeType) {
+func format(kubeDocs kubeTypes) {
 	const minDocLength = 3
 	for i, k := range kubeDocs {
 		kubeDocs[i].Doc = strings.Trim(k.Doc, "\n")
@@ -163,18 +413,32 @@ func format(kubeDocs []kubeType) {
 		kubeDocs[i].TableFieldRawType = rightPad(conf.TableFieldRawType, abs(rawTypeMaxLength-len(conf.TableFieldRawType)))
 		kubeDocs[i].TableFieldRawTypeDashSize = strings.Repeat("-", rawTypeMaxLength)
 		kubeDocs[i].TableFieldMandatory = rightPad(conf.TableFieldMandatory, abs(nameMaxLength-len(conf.TableFieldMandatory)))
+		defaultMaxLength := max(k.maxSizeOfDefault, len(conf.TableFieldDefault))
+		kubeDocs[i].TableFieldDefault = rightPad(conf.TableFieldDefault, defaultMaxLength-len(conf.TableFieldDefault))
+		kubeDocs[i].TableFieldDefaultDashSize = strings.Repeat("-", defaultMaxLength)
+		valueMaxLength := max(k.maxSizeOfValue, len(conf.TableFieldValue))
+		valueDocMaxLength := max(k.maxSizeOfValueDoc, len(conf.TableFieldDoc))
+		kubeDocs[i].TableFieldValue = rightPad(conf.TableFieldValue, valueMaxLength-len(conf.TableFieldValue))
+		kubeDocs[i].TableFieldValueDashSize = strings.Repeat("-", valueMaxLength)
+		kubeDocs[i].TableFieldValueDoc = rightPad(conf.TableFieldDoc, valueDocMaxLength-len(conf.TableFieldDoc))
+		kubeDocs[i].TableFieldValueDocDashSize = strings.Repeat("-", valueDocMaxLength)
+		for j, value := range k.Values {
+			kubeDocs[i].Values[j].Value = rightPad(value.Value, valueMaxLength-len(value.Value))
+			kubeDocs[i].Values[j].Doc = rightPad(value.Doc, valueDocMaxLength-len(value.Doc))
+		}
 		for j, item := range k.Items {
 			kubeDocs[i].Items[j].Name = rightPad(item.Name, nameMaxLength-len(item.Name))
 			kubeDocs[i].Items[j].Doc = rightPad(item.Doc, docMaxLength-len(item.Doc))
 			// adding hyperlinks to documented keys
 			kubeDocs[i].Items[j].RawType = rightPad(kubeDocs[i].Items[j].RawType, rawTypeMaxLength-len(item.RawType))
 			kubeDocs[i].Items[j].Mandatory = item.Mandatory
+			kubeDocs[i].Items[j].Default = rightPad(item.Default, defaultMaxLength-len(item.Default))
 		}
 	}
 }
 
 // runTemplate execute the template, fed by docs values
-func runTemplate(aTemplate []byte, docs []kubeType) (string, error) {
+func runTemplate(aTemplate []byte, docs kubeTypes) (string, error) {
 	var w bytes.Buffer
 	tmpl, err := template.New("KubeTypes").Parse(string(aTemplate))
 	if err != nil {
@@ -188,43 +452,210 @@ func runTemplate(aTemplate []byte, docs []kubeType) (string, error) {
 }
 
 // applyAnchor applies an anchor, in order to be compliant with MarkDown output
-func applyAnchor(name string) string {
-	return fmt.Sprintf("<a id='%v'></a>", name)
+func applyAnchor(anchorID string) string {
+	return fmt.Sprintf("<a id='%v'></a>", anchorID)
 }
 
 // applyNameWithAnchor applies an anchor and a name, in order to be compliant with MarkDown output
-func applyNameWithAnchor(name string) string {
-	return fmt.Sprintf("<a id='%v'></a>`%v`", name, name)
+func applyNameWithAnchor(anchorID string, name string) string {
+	return fmt.Sprintf("<a id='%v'></a>`%v`", anchorID, name)
 }
 
-// wrapInLink generate a Markdown link tag from a type
-func wrapInLink(info parser.TypeInfo, internalTypes map[string]bool) string {
-	if info.Internal && unicode.IsUpper([]rune(info.BaseType)[0]) {
-		// This is an internal type exported, so it is user-defined.
-		// Is this a documented type or not?
-		_, documented := internalTypes[info.BaseType]
+// typeAnchorID returns the anchor of a type, which is the type name
+// qualified, if requested, with its API group and version
+func typeAnchorID(kubeStructure parser.KubeStructure, qualified bool) string {
+	if !qualified {
+		return kubeStructure.Name
+	}
+	return anchorName(kubeStructure.GroupVersion.String() + "/" + kubeStructure.Name)
+}
+
+// anchorName converts a string to an anchor, replacing the characters
+// which are not allowed. I.e. `postgresql.k8s.enterprisedb.io/v1/Cluster`
+// becomes `postgresql-k8s-enterprisedb-io-v1-Cluster`
+func anchorName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+}
 
-		if documented {
-			// Let's use an internal link for that
-			return fmt.Sprintf("[%v](#%v)", info.Name, info.BaseType)
+// wrapInLink generate a Markdown link tag from a type. The type constructors
+// are rendered level by level, so that every named type they are built on,
+// including the map keys and the type arguments, can be linked
+func wrapInLink(info parser.TypeInfo, documentedTypes map[string]string) string {
+	switch {
+	case info.Key != nil && info.Elem != nil:
+		return fmt.Sprintf("map[%v]%v",
+			wrapInLink(*info.Key, documentedTypes), wrapInLink(*info.Elem, documentedTypes))
+
+	case info.Elem != nil:
+		return info.Constructor + wrapInLink(*info.Elem, documentedTypes)
+
+	case len(info.TypeArgs) > 0:
+		var typeArgs []string
+		for _, typeArg := range info.TypeArgs {
+			typeArgs = append(typeArgs, wrapInLink(typeArg, documentedTypes))
 		}
+		base := info
+		base.Name = info.BaseType
+		base.TypeArgs = nil
+		return fmt.Sprintf("%v[%v]", wrapInLink(base, documentedTypes), strings.Join(typeArgs, ", "))
+	}
+
+	// Is this a documented type or not? The type can be defined in
+	// the same package or in another one we are documenting
+	if anchorID, documented := documentedTypes[info.QualifiedName()]; documented {
+		// Let's use an internal link for that
+		return fmt.Sprintf("[%v](#%v)", info.Name, anchorID)
+	}
 
+	if info.Internal {
 		// We don't have documentation for this type, so we are leaving
 		// it unlinked
 		return info.Name
 	}
 
-	if !info.Internal {
-		// This is an external type so let's hope it is a Kubernetes native one
-		section, ok := conf.Sections[info.BaseType]
-		if ok {
-			return fmt.Sprintf(`[%v](%v/%v/%v)`, info.Name, conf.K8sURL, conf.Version, section)
-		}
+	// This is an external type so let's hope it is a Kubernetes native one.
+	// The sections are looked up by import path, so that the link doesn't
+	// depend on the name used to import the package, and then by the
+	// name used in the source code
+	section, ok := conf.Sections[info.QualifiedName()]
+	if !ok {
+		section, ok = conf.Sections[info.BaseType]
+	}
+	if ok {
+		return fmt.Sprintf(`[%v](%v/%v/%v)`, info.Name, conf.K8sURL, conf.Version, section)
 	}
 
 	return info.Name
 }
 
+// sourceLink generates the link to the source code declaring an element,
+// filling the configured template. I.e. the template
+// `https://github.com/org/repo/blob/{ref}/{path}#L{line}` becomes
+// `https://github.com/org/repo/blob/main/api/v1/cluster_types.go#L42`.
+// No link is generated when the template is not configured or the file
+// is outside the working directory.
+func sourceLink(pos parser.Position) string {
+	if conf.SourceLink == "" || !pos.IsValid() || filepath.IsAbs(pos.Filename) {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{ref}", conf.SourceRef,
+		"{path}", filepath.ToSlash(pos.Filename),
+		"{line}", strconv.Itoa(pos.Line),
+		"{column}", strconv.Itoa(pos.Column),
+	).Replace(conf.SourceLink)
+}
+
+// formatDeprecation describes the deprecation of a field in its table
+// cell, i.e. "**Deprecated**: use `storage` instead."
+func formatDeprecation(deprecation parser.Deprecation) string {
+	if !deprecation.Deprecated {
+		return ""
+	}
+	if deprecation.DeprecationMessage == "" {
+		return "**Deprecated**"
+	}
+	return "**Deprecated**: " + escapeCell(deprecation.DeprecationMessage)
+}
+
+// formatSubresources describes the subresources served for a Kind,
+// i.e. "`status`, `scale` (replicas: `.spec.instances` / `.status.instances`)"
+func formatSubresources(subresources parser.Subresources) string {
+	var result []string
+	if subresources.Status {
+		result = append(result, "`status`")
+	}
+	if scale := subresources.Scale; scale != nil {
+		description := fmt.Sprintf("`scale` (replicas: `%v` / `%v`", scale.SpecReplicasPath, scale.StatusReplicasPath)
+		if scale.LabelSelectorPath != "" {
+			description += fmt.Sprintf(", selector: `%v`", scale.LabelSelectorPath)
+		}
+		result = append(result, description+")")
+	}
+	return strings.Join(result, ", ")
+}
+
+// formatValidations describes the constraints of a field or a type in
+// a human-readable way, i.e. "minimum: 1, maximum: 10", escaping the
+// characters which would break a table if requested
+func formatValidations(v parser.Validations, inTable bool) string {
+	escape := func(s string) string {
+		if inTable {
+			return escapeCell(s)
+		}
+		return s
+	}
+
+	var constraints []string
+	addFloat := func(name string, value *float64) {
+		if value != nil {
+			constraints = append(constraints, fmt.Sprintf("%v: %v", name, strconv.FormatFloat(*value, 'g', -1, 64)))
+		}
+	}
+	addInt := func(name string, value *int64) {
+		if value != nil {
+			constraints = append(constraints, fmt.Sprintf("%v: %v", name, *value))
+		}
+	}
+	addString := func(name string, value string) {
+		if value != "" {
+			constraints = append(constraints, fmt.Sprintf("%v: `%v`", name, escape(value)))
+		}
+	}
+
+	if v.Minimum != nil && v.ExclusiveMinimum {
+		addFloat("exclusive minimum", v.Minimum)
+	} else {
+		addFloat("minimum", v.Minimum)
+	}
+	if v.Maximum != nil && v.ExclusiveMaximum {
+		addFloat("exclusive maximum", v.Maximum)
+	} else {
+		addFloat("maximum", v.Maximum)
+	}
+	addFloat("multiple of", v.MultipleOf)
+	addInt("min length", v.MinLength)
+	addInt("max length", v.MaxLength)
+	addString("pattern", v.Pattern)
+	addString("format", v.Format)
+	if len(v.Enum) > 0 {
+		constraints = append(constraints, fmt.Sprintf("allowed values: `%v`", escape(strings.Join(v.Enum, "`, `"))))
+	}
+	addInt("min items", v.MinItems)
+	addInt("max items", v.MaxItems)
+	if v.UniqueItems {
+		constraints = append(constraints, "unique items")
+	}
+	addInt("min properties", v.MinProperties)
+	addInt("max properties", v.MaxProperties)
+	addString("type", v.Type)
+	if v.Nullable {
+		constraints = append(constraints, "nullable")
+	}
+	for _, rule := range v.Rules {
+		if rule.Message != "" {
+			constraints = append(constraints, fmt.Sprintf("rule: `%v` (%v)", escape(rule.Rule), escape(rule.Message)))
+		} else {
+			constraints = append(constraints, fmt.Sprintf("rule: `%v`", escape(rule.Rule)))
+		}
+	}
+
+	return strings.Join(constraints, ", ")
+}
+
+// escapeCell escapes the characters which would break a table when
+// the passed text is written inside one of its cells
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -0,0 +1,364 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package md
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/diagnostics"
+	"github.com/EnterpriseDB/k8s-api-docgen/pkg/parser"
+)
+
+// The configuration and the template published with the tool
+var (
+	testConfiguration = filepath.Join("..", "..", "..", "md-configuration.yaml")
+	testTemplate      = filepath.Join("..", "..", "..", "md-template.md")
+)
+
+// unescapedPipeRegexp matches the pipes separating the cells of a table
+var unescapedPipeRegexp = regexp.MustCompile(`(^|[^\\])\|`)
+
+func TestFormatValidations(t *testing.T) {
+	minimum := 1.0
+	maxLength := int64(63)
+
+	tests := []struct {
+		name        string
+		validations parser.Validations
+		inTable     bool
+		expected    string
+	}{
+		{
+			name:        "no constraints",
+			validations: parser.Validations{},
+		},
+		{
+			name: "numbers",
+			validations: parser.Validations{
+				Minimum:          &minimum,
+				ExclusiveMinimum: true,
+				MaxLength:        &maxLength,
+			},
+			expected: "exclusive minimum: 1, max length: 63",
+		},
+		{
+			name:        "pattern in a table",
+			validations: parser.Validations{Pattern: "^(a|b)$"},
+			inTable:     true,
+			expected:    "pattern: `^(a\\|b)$`",
+		},
+		{
+			name:        "pattern outside of a table",
+			validations: parser.Validations{Pattern: "^(a|b)$"},
+			expected:    "pattern: `^(a|b)$`",
+		},
+		{
+			name: "rules in a table",
+			validations: parser.Validations{Rules: []parser.ValidationRule{
+				{Rule: "self == 'a' || self == 'b'", Message: "a|b"},
+				{Rule: "size(self) > 0"},
+			}},
+			inTable:  true,
+			expected: "rule: `self == 'a' \\|\\| self == 'b'` (a\\|b), rule: `size(self) > 0`",
+		},
+		{
+			name: "multi-line rule in a table",
+			validations: parser.Validations{Rules: []parser.ValidationRule{
+				{Rule: "self.a ||\nself.b"},
+			}},
+			inTable:  true,
+			expected: "rule: `self.a \\|\\| self.b`",
+		},
+		{
+			name:        "allowed values in a table",
+			validations: parser.Validations{Enum: []string{"a|b", "c"}},
+			inTable:     true,
+			expected:    "allowed values: `a\\|b`, `c`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := formatValidations(tt.validations, tt.inTable); result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// renderSource renders the types declared in the passed source
+// with the configuration and the template published with the tool
+func renderSource(t *testing.T, source string) (string, diagnostics.Diagnostics) {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "types.go")
+	if err := os.WriteFile(fileName, []byte(source), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kt, report, err := parser.GetKubeTypes([]string{fileName}, nil)
+	if err != nil {
+		t.Fatalf("cannot read the types: %v", err)
+	}
+
+	result, err := ToMd(kt.WithoutHidden(), testConfiguration, testTemplate, &report)
+	if err != nil {
+		t.Fatalf("cannot render the types: %v", err)
+	}
+	return result, report
+}
+
+func TestToMdTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		expected string
+		cells    int
+	}{
+		{
+			name: "pattern",
+			field: `// Mode is the mode
+	// +kubebuilder:validation:Pattern=` + "`^(a|b)$`" + `
+	Mode string ` + "`json:\"mode\"`",
+			expected: "pattern: `^(a\\|b)$`",
+		},
+		{
+			name: "CEL rule",
+			field: `// Mode is the mode
+	// +kubebuilder:validation:XValidation:rule="self == 'a' || self == 'b'",message="a or b"
+	Mode string ` + "`json:\"mode\"`",
+			expected: "rule: `self == 'a' \\|\\| self == 'b'` (a or b)",
+		},
+		{
+			name: "documentation",
+			field: `// Mode is the mode, either a|b
+	Mode string ` + "`json:\"mode\"`",
+			expected: "Mode is the mode, either a\\|b",
+		},
+		{
+			name: "list in the documentation",
+			field: `// Mode is the mode, one of:
+	//   - a
+	//   - b
+	//
+	// ---
+	// Notes for the developers
+	Mode string ` + "`json:\"mode\"`",
+			expected: "Mode is the mode, one of:<br><br><ul><li>a</li><li>b</li></ul> - *mandatory* |",
+		},
+		{
+			name: "note",
+			field: `// Mode is the mode
+	// +docgen:note=either a|b
+	Mode string ` + "`json:\"mode\"`",
+			expected: "**Note**: either a\\|b",
+		},
+		{
+			name: "default value",
+			field: `// Mode is the mode
+	// +kubebuilder:default="a|b"
+	Mode string ` + "`json:\"mode\"`",
+			expected: "`\"a\\|b\"`",
+			cells:    4,
+		},
+		{
+			name: "deprecation",
+			field: `// Mode is the mode
+	//
+	// Deprecated: use something else
+	Mode string ` + "`json:\"mode\"`",
+			expected: "~~`mode`~~",
+		},
+		{
+			name: "deprecation message",
+			field: `// Mode is the mode
+	//
+	// Deprecated: use either a|b,
+	// or nothing at all
+	Mode string ` + "`json:\"mode\"`",
+			expected: "**Deprecated**: use either a\\|b, or nothing at all",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := renderSource(t, `package v1
+
+// Foo is a foo
+type Foo struct {
+	`+tt.field+`
+}
+`)
+
+			var row string
+			for _, line := range strings.Split(result, "\n") {
+				if strings.Contains(line, "`mode`") {
+					row = line
+				}
+			}
+			if row == "" {
+				t.Fatalf("the field is not documented:\n%v", result)
+			}
+			if !strings.Contains(row, tt.expected) {
+				t.Errorf("expected the row to contain %q, got %q", tt.expected, row)
+			}
+			expectedCells := 3
+			if tt.cells != 0 {
+				expectedCells = tt.cells
+			}
+			if cells := len(unescapedPipeRegexp.FindAllString(row, -1)) + 1; cells != expectedCells {
+				t.Errorf("expected %v cells, got %v in %q", expectedCells, cells, row)
+			}
+		})
+	}
+}
+
+func TestToMdPrintColumns(t *testing.T) {
+	result, _ := renderSource(t, `package v1
+
+// Foo is a foo
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`+"`.status.conditions[?(@.type==\"Ready\")].status`"+`
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=".spec.mode",description="Either a|b"
+type Foo struct {
+}
+`)
+
+	expected := []string{
+		"Ready | string | `.status.conditions[?(@.type==\"Ready\")].status` | ",
+		"Mode | string | `.spec.mode` | Either a\\|b",
+	}
+	for _, row := range expected {
+		if !strings.Contains(result, row) {
+			t.Errorf("expected the documentation to contain %q, got:\n%v", row, result)
+		}
+	}
+}
+
+func TestToMdReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected string
+		warnings int
+	}{
+		{
+			name:     "documented type",
+			doc:      "Spec is described by [BarSpec]",
+			expected: "[BarSpec](#BarSpec)",
+		},
+		{
+			name:     "field of a documented type",
+			doc:      "Spec is described by [BarSpec.size]",
+			expected: "[BarSpec.size](#BarSpec)",
+		},
+		{
+			name:     "external type",
+			doc:      "Spec is described by [metav1.ObjectMeta]",
+			expected: "[metav1.ObjectMeta](https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.20/#objectmeta-v1-meta)",
+		},
+		{
+			name:     "plain text reference",
+			doc:      "Spec is the specification, see BarSpec",
+			expected: "see [BarSpec](#BarSpec)",
+		},
+		{
+			name:     "unknown type",
+			doc:      "Spec is described by [Unknown]",
+			expected: "Spec is described by Unknown -",
+			warnings: 1,
+		},
+		{
+			name:     "unknown field",
+			doc:      "Spec is described by [BarSpec.unknown]",
+			expected: "Spec is described by BarSpec.unknown -",
+			warnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, report := renderSource(t, `package v1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// Bar is a bar
+type Bar struct {
+	// `+tt.doc+`
+	Spec BarSpec `+"`json:\"spec\"`"+`
+}
+
+// BarSpec is the specification of a bar
+type BarSpec struct {
+	// Size is the size
+	Size string `+"`json:\"size\"`"+`
+}
+
+var _ metav1.ObjectMeta
+`)
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("expected the documentation to contain %q, got:\n%v", tt.expected, result)
+			}
+			if warnings := report.Count(diagnostics.SeverityWarning); warnings != tt.warnings {
+				t.Errorf("expected %v warnings, got %v", tt.warnings, report)
+			}
+		})
+	}
+}
+
+func TestSourceLink(t *testing.T) {
+	defer func(saved mdConfiguration) { conf = saved }(conf)
+
+	tests := []struct {
+		name       string
+		sourceLink string
+		position   parser.Position
+		expected   string
+	}{
+		{
+			name:     "not configured",
+			position: parser.Position{Filename: "api/v1/cluster_types.go", Line: 42, Column: 6},
+		},
+		{
+			name:       "relative path",
+			sourceLink: "https://github.com/org/repo/blob/{ref}/{path}#L{line}",
+			position:   parser.Position{Filename: "api/v1/cluster_types.go", Line: 42, Column: 6},
+			expected:   "https://github.com/org/repo/blob/main/api/v1/cluster_types.go#L42",
+		},
+		{
+			name:       "outside the working directory",
+			sourceLink: "https://github.com/org/repo/blob/{ref}/{path}#L{line}",
+			position:   parser.Position{Filename: filepath.Join(t.TempDir(), "types.go"), Line: 42, Column: 6},
+		},
+		{
+			name:       "unknown position",
+			sourceLink: "https://github.com/org/repo/blob/{ref}/{path}#L{line}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf = mdConfiguration{SourceLink: tt.sourceLink, SourceRef: "main"}
+			if link := sourceLink(tt.position); link != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, link)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+/*
+Copyright 2021 EnterpriseDB Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package versions contain the version of this software
+package versions
+
+const (
+	// Name is the name of this software, as written in the generated documents
+	Name = "k8s-api-docgen"
+)
+
+// Version is the version of this software. It can be set while building
+// with `-ldflags "-X github.com/EnterpriseDB/k8s-api-docgen/pkg/versions.Version=..."`
+var Version = "0.1.0-dev"